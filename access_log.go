@@ -0,0 +1,67 @@
+package traefik_block_regex_urls
+
+import (
+	"log"
+	"net/http"
+)
+
+// accessLogHandler wraps the next handler to log the status of every request passed on, e.g. 4xx and 5xx of the backend.
+type accessLogHandler struct {
+	next   http.Handler
+	logger *log.Logger
+	name   string
+}
+
+func (handler *accessLogHandler) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
+	// read before the next handler, which may rewrite the request
+	requestURL := fullURL(request)
+
+	recorder := &statusRecorder{ResponseWriter: responseWriter}
+	handler.next.ServeHTTP(recorder, request)
+
+	handler.logger.Printf("URL is passed on (status %d): (%s) middleware=%s", recorder.status(), requestURL, handler.name)
+}
+
+// statusRecorder captures the status code written by the next handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	statusCode int
+}
+
+func (recorder *statusRecorder) WriteHeader(statusCode int) {
+	// informational statuses may precede the final one
+	if recorder.statusCode == 0 && statusCode >= http.StatusOK {
+		recorder.statusCode = statusCode
+	}
+
+	recorder.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (recorder *statusRecorder) Write(body []byte) (int, error) {
+	if recorder.statusCode == 0 {
+		recorder.statusCode = http.StatusOK
+	}
+
+	return recorder.ResponseWriter.Write(body)
+}
+
+// Flush passes a flush on, for streaming backends.
+func (recorder *statusRecorder) Flush() {
+	if flusher, isFlusher := recorder.ResponseWriter.(http.Flusher); isFlusher {
+		flusher.Flush()
+	}
+}
+
+// Unwrap returns the wrapped writer, for http.ResponseController.
+func (recorder *statusRecorder) Unwrap() http.ResponseWriter {
+	return recorder.ResponseWriter
+}
+
+// status returns the captured status code, 200 if the next handler wrote neither a header nor a body.
+func (recorder *statusRecorder) status() int {
+	if recorder.statusCode == 0 {
+		return http.StatusOK
+	}
+
+	return recorder.statusCode
+}
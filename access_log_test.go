@@ -0,0 +1,67 @@
+package traefik_block_regex_urls_test
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+func Test_BlockUrls_AccessLog(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login"}
+	cfg.AccessLog = true
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/api":
+			rw.WriteHeader(http.StatusBadGateway)
+		case "/missing":
+			http.NotFound(rw, req)
+		case "/body":
+			// implicit 200
+			_, _ = rw.Write([]byte("ok"))
+		}
+	})
+
+	handler, err := BlockUrls.New(context.Background(), next, cfg, "BlockUrls")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"http://localhost/api":     "URL is passed on (status 502): (localhost/api)",
+		"http://localhost/missing": "URL is passed on (status 404): (localhost/missing)",
+		"http://localhost/body":    "URL is passed on (status 200): (localhost/body)",
+		"http://localhost/empty":   "URL is passed on (status 200): (localhost/empty)",
+	}
+
+	for url, expected := range tests {
+		var buf bytes.Buffer
+		log.SetOutput(&buf)
+
+		res := serveRequest(t, handler, url)
+
+		log.SetOutput(os.Stderr)
+
+		if !strings.Contains(buf.String(), expected) {
+			t.Errorf("expected log %q for status %d, got %q", expected, res.StatusCode, buf.String())
+		}
+	}
+
+	// blocked requests are not passed on
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	serveRequest(t, handler, "http://localhost/wp-login")
+
+	if strings.Contains(buf.String(), "passed on") {
+		t.Errorf("expected no access log line for a blocked request, got %q", buf.String())
+	}
+}
@@ -0,0 +1,134 @@
+package traefik_block_regex_urls
+
+// ahoCorasick is an Aho-Corasick automaton over the bytes of the strings, finding which of them a target contains
+// in a single pass, whatever their number, instead of one strings.Contains scan per string.
+type ahoCorasick struct {
+	strings []string
+	// next holds the transitions of each state, the root is state 0.
+	next []map[byte]int32
+	// fail is the state of the longest proper suffix of a state which is also a prefix of a string.
+	fail []int32
+	// output is the lowest index of the strings ending in a state or its fail chain, -1 if none.
+	output []int32
+}
+
+// newAhoCorasick builds the automaton of the strings. Returns nil without strings.
+func newAhoCorasick(matchStrings []string) *ahoCorasick {
+	if len(matchStrings) == 0 {
+		return nil
+	}
+
+	automaton := &ahoCorasick{
+		strings: matchStrings,
+		next:    []map[byte]int32{{}},
+		fail:    []int32{0},
+		output:  []int32{-1},
+	}
+
+	for index, matchString := range matchStrings {
+		state := int32(0)
+
+		for position := 0; position < len(matchString); position++ {
+			nextState, found := automaton.next[state][matchString[position]]
+			if !found {
+				nextState = int32(len(automaton.next))
+				automaton.next[state][matchString[position]] = nextState
+				automaton.next = append(automaton.next, map[byte]int32{})
+				automaton.fail = append(automaton.fail, 0)
+				automaton.output = append(automaton.output, -1)
+			}
+
+			state = nextState
+		}
+
+		// duplicates keep their first index, like the strings.Contains loop
+		if automaton.output[state] < 0 {
+			automaton.output[state] = int32(index)
+		}
+	}
+
+	// breadth first, so the fail state of a state is complete before its children are linked
+	queue := make([]int32, 0, len(automaton.next))
+	for _, child := range automaton.next[0] {
+		queue = append(queue, child)
+	}
+
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+
+		for character, child := range automaton.next[state] {
+			queue = append(queue, child)
+
+			failState := automaton.fail[state]
+			for failState != 0 && !automaton.hasNext(failState, character) {
+				failState = automaton.fail[failState]
+			}
+
+			if target, found := automaton.next[failState][character]; found && target != child {
+				automaton.fail[child] = target
+			}
+
+			automaton.output[child] = lowestOutput(automaton.output[child], automaton.output[automaton.fail[child]])
+		}
+	}
+
+	return automaton
+}
+
+func (automaton *ahoCorasick) hasNext(state int32, character byte) bool {
+	_, found := automaton.next[state][character]
+
+	return found
+}
+
+// find returns the first string, in list order, which the target contains, or false if none.
+// The result is the same as testing each string with strings.Contains in order.
+func (automaton *ahoCorasick) find(target string) (string, bool) {
+	state := int32(0)
+	best := automaton.output[0]
+
+	for position := 0; position < len(target) && best != 0; position++ {
+		character := target[position]
+
+		for {
+			if nextState, found := automaton.next[state][character]; found {
+				state = nextState
+				break
+			}
+
+			if state == 0 {
+				break
+			}
+
+			state = automaton.fail[state]
+		}
+
+		best = lowestOutput(best, automaton.output[state])
+	}
+
+	if best < 0 {
+		return "", false
+	}
+
+	return automaton.strings[best], true
+}
+
+// lowestOutput returns the lower of two string indexes, where -1 means none.
+func lowestOutput(first int32, second int32) int32 {
+	if first < 0 || (second >= 0 && second < first) {
+		return second
+	}
+
+	return first
+}
+
+// buildAutomaton returns the automaton of the strings if ahoCorasick is set, nil otherwise.
+// Only contains matching is a substring search, the other string match modes keep the loop.
+func (blockUrls *traefik_block_regex_urls) buildAutomaton(matchStrings []string) *ahoCorasick {
+	if !blockUrls.ahoCorasick || blockUrls.stringMatchMode != stringMatchContains {
+		return nil
+	}
+
+	return newAhoCorasick(matchStrings)
+}
@@ -0,0 +1,97 @@
+package traefik_block_regex_urls_test
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+func Test_BlockUrls_AhoCorasick(t *testing.T) {
+	// overlapping strings, suffixes of each other and listed out of match order
+	matchStrings := []string{"hers", "his", "she", "he", "/ushers", "admin/", "/admin", "she"}
+
+	urls := []string{
+		"http://localhost/ushers",
+		"http://localhost/she",
+		"http://localhost/his-hers",
+		"http://localhost/ahishe",
+		"http://localhost/admin/",
+		"http://localhost/h",
+		"http://localhost/index.html",
+		"http://localhost/",
+	}
+
+	for _, url := range urls {
+		expectedStatus, expectedPattern := blockedPattern(t, matchStrings, false, url)
+		status, pattern := blockedPattern(t, matchStrings, true, url)
+
+		if status != expectedStatus || pattern != expectedPattern {
+			t.Errorf("%s: expected status %d and pattern %q as without ahoCorasick, got %d and %q", url, expectedStatus, expectedPattern, status, pattern)
+		}
+	}
+}
+
+func Test_BlockUrls_AhoCorasick_NoStrings(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.AhoCorasick = true
+	cfg.Regex = []string{"^localhost/wp(.*)"}
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/index.html"), http.StatusOK)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-login.php"), http.StatusForbidden)
+}
+
+func Test_BlockUrls_AhoCorasick_OtherStringMatchMode(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.AhoCorasick = true
+	cfg.MatchScope = "path"
+	cfg.StringMatchMode = "prefix"
+	cfg.Strings = []string{"/wp-"}
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-login.php"), http.StatusForbidden)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/blog/wp-login.php"), http.StatusOK)
+}
+
+func Benchmark_BlockUrls_NoMatch_ManyStrings(b *testing.B) {
+	benchmarkServeHTTP(b, manyStringsConfig(false), "http://localhost/index.html?page=1")
+}
+
+func Benchmark_BlockUrls_NoMatch_ManyStrings_AhoCorasick(b *testing.B) {
+	benchmarkServeHTTP(b, manyStringsConfig(true), "http://localhost/index.html?page=1")
+}
+
+// blockedPattern returns the status of a request and the pattern it was blocked by, if any.
+func blockedPattern(t *testing.T, matchStrings []string, ahoCorasick bool, url string) (int, string) {
+	t.Helper()
+
+	cfg := BlockUrls.CreateConfig()
+	cfg.MatchScope = "path"
+	cfg.Strings = matchStrings
+	cfg.AhoCorasick = ahoCorasick
+
+	handler := newHandler(t, cfg)
+	res := serveRequest(t, handler, url)
+
+	for pattern := range handler.(statsReporter).PatternStats().Patterns {
+		return res.StatusCode, pattern
+	}
+
+	return res.StatusCode, ""
+}
+
+func manyStringsConfig(ahoCorasick bool) *BlockUrls.Config {
+	cfg := BlockUrls.CreateConfig()
+	cfg.MatchScope = "path"
+	cfg.AhoCorasick = ahoCorasick
+
+	for i := 0; i < 5000; i++ {
+		cfg.Strings = append(cfg.Strings, fmt.Sprintf("/scanner-%d", i))
+	}
+
+	return cfg
+}
@@ -0,0 +1,74 @@
+package traefik_block_regex_urls
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// matchedRulesHeader is the response header listing every matching pattern with reportAllMatches.
+const matchedRulesHeader = "X-Matched-Rules"
+
+// allMatches runs the request against every exact match, string, regex, rule and rule set, without stopping
+// at the first match, and returns the matching patterns in that order. This costs a full scan per tagged request.
+func (blockUrls *traefik_block_regex_urls) allMatches(request *http.Request) []string {
+	blockUrls.compileLazyRegex()
+
+	blockUrls.mu.RLock()
+	regexps := blockUrls.regexps
+	matchStrings := blockUrls.matchStrings
+	blockUrls.mu.RUnlock()
+
+	if blockUrls.disableStringMatch {
+		matchStrings = nil
+	}
+
+	target := blockUrls.matchTarget(request)
+	patterns := []string{}
+
+	add := func(pattern string) {
+		if !slices.Contains(patterns, pattern) {
+			patterns = append(patterns, pattern)
+		}
+	}
+
+	if slices.Contains(blockUrls.exactMatch, target) {
+		add(target)
+	}
+
+	for _, matchString := range matchStrings {
+		if blockUrls.matchesString(target, matchString) {
+			add(matchString)
+		}
+	}
+
+	for _, regex := range regexps {
+		if regex.MatchString(target) {
+			add(regex.String())
+		}
+	}
+
+	for _, matchedRule := range blockUrls.rules {
+		if blockUrls.appliesToMethod(matchedRule, request.Method) && matchedRule.regex.MatchString(target) {
+			add(matchedRule.regex.String())
+		}
+	}
+
+	for _, set := range blockUrls.ruleSets {
+		setTarget := blockUrls.scopedTarget(request, set.matchScope)
+
+		for _, matchString := range set.matchStrings {
+			if strings.Contains(setTarget, matchString) {
+				add(matchString)
+			}
+		}
+
+		for _, regex := range set.regexps {
+			if regex.MatchString(setTarget) {
+				add(regex.String())
+			}
+		}
+	}
+
+	return patterns
+}
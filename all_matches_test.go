@@ -0,0 +1,46 @@
+package traefik_block_regex_urls_test
+
+import (
+	"net/http"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+func Test_BlockUrls_ReportAllMatches(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.MatchScope = "path"
+	cfg.Strings = []string{"wp-"}
+	cfg.Regex = []string{"^/wp", `\.php$`, "^/xmlrpc"}
+	cfg.Rules = []BlockUrls.Rule{{Regex: "login"}, {Regex: "^/wp", StatusCode: 404}}
+	cfg.Mode = "tag"
+	cfg.ReportAllMatches = true
+
+	handler := newHandler(t, cfg)
+
+	res := serveRequest(t, handler, "http://localhost/wp-login.php")
+
+	assertStatusCode(t, res, http.StatusOK)
+
+	if matched := res.Header.Get("X-Matched-Rules"); matched != `wp-,^/wp,\.php$,login` {
+		t.Errorf("invalid matched rules header: %q", matched)
+	}
+
+	res = serveRequest(t, handler, "http://localhost/index.html")
+
+	if matched, found := res.Header["X-Matched-Rules"]; found {
+		t.Errorf("expected no matched rules header, got %q", matched)
+	}
+
+	cfg.Mode = "block"
+
+	handler = newHandler(t, cfg)
+
+	res = serveRequest(t, handler, "http://localhost/wp-login.php")
+
+	assertStatusCode(t, res, http.StatusForbidden)
+
+	if matched, found := res.Header["X-Matched-Rules"]; found {
+		t.Errorf("expected no matched rules header in block mode, got %q", matched)
+	}
+}
@@ -0,0 +1,105 @@
+package traefik_block_regex_urls
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// AllOf groups conditions which block a request only when all of them match, for precise rules with few false positives.
+// Unset conditions are ignored, at least one must be set.
+type AllOf struct {
+	// Regex is matched against the url, in the matchScope.
+	Regex          string `yaml:"regex,omitempty"`
+	UserAgentRegex string `yaml:"userAgentRegex,omitempty"`
+	// QueryKeys must all be present in the query.
+	QueryKeys  []string `yaml:"queryKeys,omitempty"`
+	StatusCode int      `yaml:"statusCode,omitempty"`
+}
+
+// allOf is a compiled AllOf.
+type allOf struct {
+	regex          *regexp.Regexp
+	userAgentRegex *regexp.Regexp
+	queryKeys      []string
+	pattern        string
+	response       *rule
+}
+
+// compileAllOf compiles the AllOf groups.
+func compileAllOf(groups []AllOf) ([]*allOf, error) {
+	compiledGroups := make([]*allOf, len(groups))
+
+	for index, group := range groups {
+		if group.Regex == "" && group.UserAgentRegex == "" && len(group.QueryKeys) == 0 {
+			return nil, fmt.Errorf("error in allOf %d: no condition set", index)
+		}
+
+		compiledGroup := &allOf{queryKeys: group.QueryKeys, response: &rule{statusCode: group.StatusCode}}
+		conditions := []string{}
+
+		if group.Regex != "" {
+			regex, compileError := regexp.Compile(group.Regex)
+			if compileError != nil {
+				return nil, fmt.Errorf("error in allOf %d: %w", index, compileError)
+			}
+
+			compiledGroup.regex = regex
+			conditions = append(conditions, "regex="+group.Regex)
+		}
+
+		if group.UserAgentRegex != "" {
+			regex, compileError := regexp.Compile(group.UserAgentRegex)
+			if compileError != nil {
+				return nil, fmt.Errorf("error in allOf %d: %w", index, compileError)
+			}
+
+			compiledGroup.userAgentRegex = regex
+			conditions = append(conditions, "userAgentRegex="+group.UserAgentRegex)
+		}
+
+		if len(group.QueryKeys) > 0 {
+			conditions = append(conditions, "queryKeys="+strings.Join(group.QueryKeys, ","))
+		}
+
+		compiledGroup.pattern = strings.Join(conditions, " ")
+		compiledGroups[index] = compiledGroup
+	}
+
+	return compiledGroups, nil
+}
+
+// matches reports whether every condition of the group matches the request.
+func (group *allOf) matches(request *http.Request, target string) bool {
+	if group.regex != nil && !group.regex.MatchString(target) {
+		return false
+	}
+
+	if group.userAgentRegex != nil && !group.userAgentRegex.MatchString(request.UserAgent()) {
+		return false
+	}
+
+	if len(group.queryKeys) > 0 {
+		query := request.URL.Query()
+
+		for _, key := range group.queryKeys {
+			if !query.Has(key) {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+// matchAllOf returns the first group whose conditions all match.
+func (blockUrls *traefik_block_regex_urls) matchAllOf(request *http.Request, target string) *match {
+	for _, group := range blockUrls.allOf {
+		if group.matches(request, target) {
+			return &match{reason: "all of match", url: fullURL(request), pattern: group.pattern, rule: group.response}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package traefik_block_regex_urls_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+func Test_BlockUrls_AllOf_BlocksOnlyIfEveryConditionMatches(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.MatchScope = "path"
+	cfg.AllOf = []BlockUrls.AllOf{
+		{Regex: "^/export", UserAgentRegex: "(?i)python-requests", QueryKeys: []string{"format", "all"}, StatusCode: 429},
+	}
+
+	handler := newHandler(t, cfg)
+
+	tests := []struct {
+		name      string
+		url       string
+		userAgent string
+		expected  int
+	}{
+		{"all conditions", "http://localhost/export?format=csv&all=1", "python-requests/2.31", http.StatusTooManyRequests},
+		{"other user agent", "http://localhost/export?format=csv&all=1", "Mozilla/5.0", http.StatusOK},
+		{"missing query key", "http://localhost/export?format=csv", "python-requests/2.31", http.StatusOK},
+		{"other path", "http://localhost/import?format=csv&all=1", "python-requests/2.31", http.StatusOK},
+	}
+
+	for _, test := range tests {
+		response := serveRequestWithHeaders(t, handler, test.url, map[string]string{"User-Agent": test.userAgent})
+		if response.StatusCode != test.expected {
+			t.Errorf("%s: unexpected status code %d, expected %d", test.name, response.StatusCode, test.expected)
+		}
+	}
+}
+
+func Test_BlockUrls_AllOf_ReturnsError_IfEmpty(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.AllOf = []BlockUrls.AllOf{{StatusCode: 404}}
+
+	if _, err := BlockUrls.New(context.Background(), nil, cfg, "BlockUrls"); err == nil {
+		t.Fatal("expected an error for an allOf without conditions")
+	}
+}
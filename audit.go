@@ -0,0 +1,81 @@
+package traefik_block_regex_urls
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// auditEntry is one line of the audit file.
+type auditEntry struct {
+	Time   string `json:"time"`
+	IP     string `json:"ip"`
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Reason string `json:"reason"`
+	// Middleware is the instance name, set on write.
+	Middleware string `json:"middleware"`
+}
+
+// auditLog appends block decisions as json lines to a file.
+type auditLog struct {
+	name   string
+	logger *log.Logger
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// openAuditLog opens the file for appending, creating it if needed.
+// The file is closed when ctx is done. Returns nil if the file cannot be opened, which disables auditing.
+// Entries and errors are tagged with the middleware name, errors go to the logger of the middleware.
+func openAuditLog(ctx context.Context, path string, name string, logger *log.Logger) *auditLog {
+	file, openError := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o640)
+	if openError != nil {
+		logger.Printf("error opening audit file %q, auditing is disabled: %v middleware=%s", path, openError, name)
+		return nil
+	}
+
+	audit := &auditLog{name: name, logger: logger, file: file}
+
+	go func() {
+		<-ctx.Done()
+
+		audit.mu.Lock()
+		defer audit.mu.Unlock()
+
+		_ = audit.file.Close()
+		audit.file = nil
+	}()
+
+	return audit
+}
+
+func (audit *auditLog) write(entry auditEntry) {
+	entry.Middleware = audit.name
+
+	line, marshalError := json.Marshal(entry)
+	if marshalError != nil {
+		audit.logger.Printf("error encoding audit entry: %v middleware=%s", marshalError, audit.name)
+		return
+	}
+
+	audit.mu.Lock()
+	defer audit.mu.Unlock()
+
+	if audit.file == nil {
+		return
+	}
+
+	if _, writeError := audit.file.Write(append(line, '\n')); writeError != nil {
+		audit.logger.Printf("error writing audit entry: %v middleware=%s", writeError, audit.name)
+	}
+}
+
+// formatAuditTime formats the time of an audit entry.
+func formatAuditTime(instant time.Time) string {
+	return instant.UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,143 @@
+package traefik_block_regex_urls_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+func Test_BlockUrls_AuditFile_AppendsBlocks(t *testing.T) {
+	auditFile := filepath.Join(t.TempDir(), "audit.log")
+
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login"}
+	cfg.AuditFile = auditFile
+	cfg.StatusCode = 404
+
+	clock := newFakeClock()
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	handler, err := BlockUrls.NewWithOptions(context.Background(), next, cfg, "BlockUrls", BlockUrls.WithClock(clock.Now))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := map[string]string{"X-Forwarded-For": "2.56.20.1"}
+
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/wp-login?x=1", client), http.StatusNotFound)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/index.html", client), http.StatusOK)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/wp-login", client), http.StatusNotFound)
+
+	content, err := os.ReadFile(auditFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 audit lines, got %q", content)
+	}
+
+	var entry map[string]string
+	if err := json.Unmarshal([]byte(lines[0]), &entry); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]string{
+		"time":   "2025-06-02T12:00:00Z",
+		"ip":     "2.56.20.1",
+		"method": "GET",
+		"url":    "localhost/wp-login?x=1",
+		"reason": "regex match",
+	}
+
+	for key, value := range expected {
+		if entry[key] != value {
+			t.Errorf("invalid audit %s: %q <> %q", key, value, entry[key])
+		}
+	}
+}
+
+func Test_BlockUrls_AuditFile_IsDisabled_IfNotWritable(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login"}
+	cfg.AuditFile = filepath.Join(t.TempDir(), "missing", "audit.log")
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-login"), http.StatusNotFound)
+}
+
+func Test_BlockUrls_AuditAllowlistedMatches(t *testing.T) {
+	auditFile := filepath.Join(t.TempDir(), "audit.log")
+
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login"}
+	cfg.BypassIPs = []string{"2.56.20.0/24"}
+	cfg.AuditAllowlistedMatches = true
+	cfg.AuditFile = auditFile
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	var output bytes.Buffer
+
+	log.SetOutput(&output)
+	defer log.SetOutput(os.Stderr)
+
+	pentester := map[string]string{"X-Forwarded-For": "2.56.20.1"}
+
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/wp-login", pentester), http.StatusOK)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/index.html", pentester), http.StatusOK)
+
+	if !strings.Contains(output.String(), "Allowlisted IP matched a blocked rule (regex match): (localhost/wp-login) ip=2.56.20.1") {
+		t.Errorf("expected the allowlisted match to be logged, got %q", output.String())
+	}
+
+	if strings.Count(output.String(), "Allowlisted IP matched") != 1 {
+		t.Errorf("expected only the matching request to be logged, got %q", output.String())
+	}
+
+	content, err := os.ReadFile(auditFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(content), `"reason":"allowlisted: regex match"`) {
+		t.Errorf("expected an allowlisted audit entry, got %q", content)
+	}
+
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/wp-login", map[string]string{"X-Forwarded-For": "2.56.21.1"}), http.StatusNotFound)
+}
+
+func Test_BlockUrls_AuditAllowlistedMatches_CountsNothing(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.MatchScope = "path"
+	cfg.Rules = []BlockUrls.Rule{{Regex: "^/api/password-reset", StatusCode: 429, RateLimit: 1}}
+	cfg.BypassIPs = []string{"2.56.20.0/24"}
+	cfg.AuditAllowlistedMatches = true
+
+	handler := newHandler(t, cfg)
+
+	var output bytes.Buffer
+
+	log.SetOutput(&output)
+	defer log.SetOutput(os.Stderr)
+
+	for i := 0; i < 3; i++ {
+		assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/api/password-reset", map[string]string{"X-Forwarded-For": "2.56.20.1"}), http.StatusOK)
+	}
+
+	// the allowlisted requests did not use up the rate limit of everyone else
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/api/password-reset", map[string]string{"X-Forwarded-For": "198.51.100.1"}), http.StatusOK)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/api/password-reset", map[string]string{"X-Forwarded-For": "198.51.100.1"}), http.StatusTooManyRequests)
+}
@@ -0,0 +1,60 @@
+package traefik_block_regex_urls
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// parseBlockDelay parses blockDelay and blockDelayJitter, empty values are no delay and no jitter.
+func parseBlockDelay(config *Config) (time.Duration, time.Duration, error) {
+	var delay, jitter time.Duration
+
+	if config.BlockDelay != "" {
+		parsedDelay, parseError := time.ParseDuration(config.BlockDelay)
+		if parseError != nil || parsedDelay < 0 {
+			return 0, 0, fmt.Errorf("invalid blockDelay %q", config.BlockDelay)
+		}
+
+		delay = parsedDelay
+	}
+
+	if config.BlockDelayJitter != "" {
+		parsedJitter, parseError := time.ParseDuration(config.BlockDelayJitter)
+		if parseError != nil || parsedJitter < 0 {
+			return 0, 0, fmt.Errorf("invalid blockDelayJitter %q", config.BlockDelayJitter)
+		}
+
+		jitter = parsedJitter
+	}
+
+	return delay, jitter, nil
+}
+
+// blockDelayFor returns the delay of a blocked request: blockDelay plus or minus a random jitter
+// of up to blockDelayJitter, so the delay is no fingerprint of the plugin. Never negative.
+func (blockUrls *traefik_block_regex_urls) blockDelayFor() time.Duration {
+	delay := blockUrls.blockDelay
+
+	if blockUrls.blockDelayJitter > 0 {
+		delay += time.Duration(blockUrls.randInt63n(2*int64(blockUrls.blockDelayJitter)+1)) - blockUrls.blockDelayJitter
+	}
+
+	return max(delay, 0)
+}
+
+// tarpit holds a blocked request for its block delay, to slow scanners down, or until the client goes away.
+func (blockUrls *traefik_block_regex_urls) tarpit(request *http.Request) {
+	delay := blockUrls.blockDelayFor()
+	if delay <= 0 {
+		return
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-request.Context().Done():
+	}
+}
@@ -0,0 +1,70 @@
+package traefik_block_regex_urls_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+// timeBlock returns how long the handler took to block the request.
+func timeBlock(t *testing.T, handler http.Handler) time.Duration {
+	t.Helper()
+
+	start := time.Now()
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-login"), http.StatusForbidden)
+
+	return time.Since(start)
+}
+
+func Test_BlockUrls_BlockDelayJitter(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login"}
+	cfg.BlockDelay = "100ms"
+	cfg.BlockDelayJitter = "50ms"
+
+	// slack for a busy test machine, well below the jitter range
+	const slack = 40 * time.Millisecond
+
+	tests := []struct {
+		name     string
+		random   func(n int64) int64
+		expected time.Duration
+	}{
+		{name: "lowest", random: func(int64) int64 { return 0 }, expected: 50 * time.Millisecond},
+		{name: "middle", random: func(n int64) int64 { return n / 2 }, expected: 100 * time.Millisecond},
+		{name: "highest", random: func(n int64) int64 { return n - 1 }, expected: 150 * time.Millisecond},
+	}
+
+	for _, test := range tests {
+		handler, err := BlockUrls.NewWithOptions(context.Background(), nil, cfg, "BlockUrls", BlockUrls.WithRand(test.random))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if elapsed := timeBlock(t, handler); elapsed < test.expected || elapsed >= test.expected+slack {
+			t.Errorf("%s jitter: expected a delay of %s, got %s", test.name, test.expected, elapsed)
+		}
+	}
+
+	// the default random source stays within blockDelay ± blockDelayJitter
+	handler := newHandler(t, cfg)
+
+	for index := 0; index < 3; index++ {
+		if elapsed := timeBlock(t, handler); elapsed < 50*time.Millisecond || elapsed >= 150*time.Millisecond+slack {
+			t.Errorf("expected a delay within 50ms and 150ms, got %s", elapsed)
+		}
+	}
+}
+
+func Test_BlockUrls_BlockDelay_ReturnsError_IfInvalid(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.BlockDelay = "1s"
+	cfg.BlockDelayJitter = "-1s"
+
+	if _, err := BlockUrls.New(context.Background(), nil, cfg, "BlockUrls"); err == nil {
+		t.Fatal("expected an error for a negative blockDelayJitter")
+	}
+}
@@ -0,0 +1,57 @@
+package traefik_block_regex_urls
+
+import (
+	"encoding/json"
+	"html"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// renderBodyTemplate replaces the tokens of the template with the values of the blocked request,
+// escaped for the content type: HTML escaped for text/html, JSON string escaped for JSON types, as is otherwise.
+// Supported tokens: {method}, {host}, {path}, {ip}, {pattern}, {reason} and {status}.
+func (blockUrls *traefik_block_regex_urls) renderBodyTemplate(template string, contentType string, request *http.Request, blockMatch *match, statusCode int) string {
+	escape := templateEscaper(contentType)
+
+	replacer := strings.NewReplacer(
+		"{method}", escape(request.Method),
+		"{host}", escape(request.Host),
+		"{path}", escape(request.URL.Path),
+		"{ip}", escape(blockMatch.clientIP),
+		"{pattern}", escape(blockMatch.pattern),
+		"{reason}", escape(blockMatch.reason),
+		"{status}", strconv.Itoa(statusCode),
+	)
+
+	return replacer.Replace(template)
+}
+
+// templateEscaper returns the escape function for values rendered into a body of the content type.
+func templateEscaper(contentType string) func(string) string {
+	if contentType == "" {
+		contentType = defaultContentType
+	}
+
+	mediaType, _, parseError := mime.ParseMediaType(contentType)
+	if parseError != nil {
+		return html.EscapeString
+	}
+
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml":
+		return html.EscapeString
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return escapeJSONString
+	default:
+		return func(value string) string { return value }
+	}
+}
+
+// escapeJSONString escapes the value for use inside a JSON string, without the surrounding quotes.
+func escapeJSONString(value string) string {
+	encoded, _ := json.Marshal(value)
+
+	return string(encoded[1 : len(encoded)-1])
+}
@@ -0,0 +1,66 @@
+package traefik_block_regex_urls_test
+
+import (
+	"net/http"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+func Test_BlockUrls_BodyTemplate_EscapesForHTML(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/admin"}
+	cfg.BlockContentType = "text/html; charset=utf-8"
+	cfg.BodyTemplate = "<p>{method} {path} from {ip} blocked by {pattern} ({status})</p>"
+
+	handler := newHandler(t, cfg)
+
+	response := serveRequestWithHeaders(t, handler, "http://localhost/admin/%3Cscript%3E", map[string]string{"X-Forwarded-For": "203.0.113.9"})
+	assertStatusCode(t, response, http.StatusForbidden)
+	assertBody(t, response, "text/html; charset=utf-8",
+		"<p>GET /admin/&lt;script&gt; from 203.0.113.9 blocked by (.*)/admin (403)</p>")
+}
+
+func Test_BlockUrls_BodyTemplate_EscapesForJSON(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{`(.*)/admin`}
+	cfg.StatusCode = 404
+	cfg.BlockContentType = "application/json"
+	cfg.BodyTemplate = `{"path":"{path}","pattern":"{pattern}","status":{status}}`
+
+	handler := newHandler(t, cfg)
+
+	response := serveRequest(t, handler, "http://localhost/admin/%22quoted%22")
+	assertStatusCode(t, response, http.StatusNotFound)
+	assertBody(t, response, "application/json", `{"path":"/admin/\"quoted\"","pattern":"(.*)/admin","status":404}`)
+}
+
+func Test_BlockUrls_BlockBody_NegotiatedByAccept(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/admin"}
+	cfg.BlockBody = "blocked"
+	cfg.BlockBodyJSON = `{"error":"blocked","path":"{path}"}`
+	cfg.BlockBodyHTML = "<h1>Blocked {path}</h1>"
+
+	handler := newHandler(t, cfg)
+
+	tests := []struct {
+		accept      string
+		contentType string
+		body        string
+	}{
+		{"application/json", "application/json; charset=utf-8", `{"error":"blocked","path":"/admin"}`},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "text/html; charset=utf-8", "<h1>Blocked /admin</h1>"},
+		{"text/html;q=0.5, application/json", "application/json; charset=utf-8", `{"error":"blocked","path":"/admin"}`},
+		{"application/*", "application/json; charset=utf-8", `{"error":"blocked","path":"/admin"}`},
+		{"application/json;q=0, text/plain", "text/plain; charset=utf-8", "blocked"},
+		{"*/*", "text/plain; charset=utf-8", "blocked"},
+		{"", "text/plain; charset=utf-8", "blocked"},
+	}
+
+	for _, test := range tests {
+		response := serveRequestWithHeaders(t, handler, "http://localhost/admin", map[string]string{"Accept": test.accept})
+		assertStatusCode(t, response, http.StatusForbidden)
+		assertBody(t, response, test.contentType, test.body)
+	}
+}
@@ -0,0 +1,37 @@
+package traefik_block_regex_urls
+
+import "fmt"
+
+// CatchAll is the response applied last, to every request which no allow rule passed on and no block rule matched,
+// like defaultDeny with its own response settings. Unset fields fall back to the global ones.
+type CatchAll struct {
+	StatusCode  int    `yaml:"statusCode,omitempty"`
+	Action      string `yaml:"action,omitempty"`
+	RewritePath string `yaml:"rewritePath,omitempty"`
+	Body        string `yaml:"body,omitempty"`
+	ContentType string `yaml:"contentType,omitempty"`
+}
+
+// compileCatchAll returns the response rule of the catch-all, or nil if there is none.
+func compileCatchAll(catchAll *CatchAll, globalRewritePath string) (*rule, error) {
+	if catchAll == nil {
+		return nil, nil
+	}
+
+	rewritePath := catchAll.RewritePath
+	if rewritePath == "" {
+		rewritePath = globalRewritePath
+	}
+
+	if actionError := validateAction(catchAll.Action, rewritePath); actionError != nil {
+		return nil, fmt.Errorf("error in catchAll: %w", actionError)
+	}
+
+	return &rule{
+		statusCode:  catchAll.StatusCode,
+		body:        catchAll.Body,
+		contentType: catchAll.ContentType,
+		action:      catchAll.Action,
+		rewritePath: catchAll.RewritePath,
+	}, nil
+}
@@ -0,0 +1,60 @@
+package traefik_block_regex_urls_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+func Test_BlockUrls_CatchAll(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login"}
+	cfg.AllowRegex = []string{"^localhost/(app|static)/"}
+	cfg.CatchAll = &BlockUrls.CatchAll{StatusCode: http.StatusNotFound, Body: "not found"}
+
+	handler := newHandler(t, cfg)
+
+	// nothing allowed the request, the catch-all applies
+	res := serveRequest(t, handler, "http://localhost/unknown")
+	assertStatusCode(t, res, http.StatusNotFound)
+	assertBody(t, res, "text/plain; charset=utf-8", "not found")
+
+	// an allow rule preempts the catch-all
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/app/index.html"), http.StatusOK)
+
+	// a block rule keeps its own response
+	res = serveRequest(t, handler, "http://localhost/wp-login")
+	assertStatusCode(t, res, http.StatusForbidden)
+	assertEmptyBody(t, res)
+}
+
+func Test_BlockUrls_CatchAll_Rewrite(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.AllowRegex = []string{"^localhost/app/"}
+	cfg.CatchAll = &BlockUrls.CatchAll{Action: "rewrite", RewritePath: "/app/landing"}
+
+	var path string
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		path = req.URL.Path
+	})
+
+	handler, err := BlockUrls.New(context.Background(), next, cfg, "BlockUrls")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/unknown"), http.StatusOK)
+
+	if path != "/app/landing" {
+		t.Errorf("expected the catch-all rewrite, got path %q", path)
+	}
+
+	cfg.CatchAll = &BlockUrls.CatchAll{Action: "rewrite"}
+
+	if _, err := BlockUrls.New(context.Background(), next, cfg, "BlockUrls"); err == nil {
+		t.Fatal("expected an error for a rewrite catch-all without rewritePath")
+	}
+}
@@ -0,0 +1,36 @@
+package traefik_block_regex_urls
+
+import (
+	"net/http"
+	"regexp"
+)
+
+// clientCertCN returns the subject common name of the leaf client certificate, or false without a client certificate.
+func clientCertCN(request *http.Request) (string, bool) {
+	if request.TLS == nil || len(request.TLS.PeerCertificates) == 0 {
+		return "", false
+	}
+
+	return request.TLS.PeerCertificates[0].Subject.CommonName, true
+}
+
+// matchClientCertCN returns the first regex matching the client certificate common name, or nil.
+// Requests without a client certificate never match.
+func matchClientCertCN(regexps []*regexp.Regexp, request *http.Request) *regexp.Regexp {
+	if len(regexps) == 0 {
+		return nil
+	}
+
+	commonName, present := clientCertCN(request)
+	if !present {
+		return nil
+	}
+
+	for _, regex := range regexps {
+		if regex.MatchString(commonName) {
+			return regex
+		}
+	}
+
+	return nil
+}
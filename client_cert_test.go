@@ -0,0 +1,75 @@
+package traefik_block_regex_urls_test
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+func serveRequestWithClientCert(t *testing.T, handler http.Handler, url string, commonName string) *http.Response {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, url, nil)
+	if commonName != "" {
+		req.TLS = &tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: commonName}}},
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+
+	return recorder.Result()
+}
+
+func Test_BlockUrls_ClientCertCNRegex_BlocksMatchingSubject(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.ClientCertCNRegex = []string{"^revoked-"}
+
+	handler := newHandler(t, cfg)
+
+	tests := []struct {
+		commonName string
+		expected   int
+	}{
+		{"revoked-device-17", http.StatusForbidden},
+		{"device-18", http.StatusOK},
+		{"", http.StatusOK},
+	}
+
+	for _, test := range tests {
+		response := serveRequestWithClientCert(t, handler, "https://localhost/", test.commonName)
+		if response.StatusCode != test.expected {
+			t.Errorf("%q: unexpected status code %d, expected %d", test.commonName, response.StatusCode, test.expected)
+		}
+	}
+}
+
+func Test_BlockUrls_AllowClientCertCNRegex_SkipsRules(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/admin"}
+	cfg.AllowClientCertCNRegex = []string{`^ops\.example\.com$`}
+
+	handler := newHandler(t, cfg)
+
+	tests := []struct {
+		commonName string
+		expected   int
+	}{
+		{"ops.example.com", http.StatusOK},
+		{"dev.example.com", http.StatusForbidden},
+		{"", http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		response := serveRequestWithClientCert(t, handler, "https://localhost/admin", test.commonName)
+		if response.StatusCode != test.expected {
+			t.Errorf("%q: unexpected status code %d, expected %d", test.commonName, response.StatusCode, test.expected)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package traefik_block_regex_urls
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// combineRegex joins the regexps, and the strings as escaped literals anchored for the string match mode,
+// into a single alternation, so a target is scanned once instead of once per pattern. Returns nil if there is nothing to combine.
+func combineRegex(regexps []*regexp.Regexp, matchStrings []string, stringMatchMode string) (*regexp.Regexp, error) {
+	if len(regexps)+len(matchStrings) == 0 {
+		return nil, nil
+	}
+
+	alternatives := make([]string, 0, len(regexps)+len(matchStrings))
+
+	for _, regex := range regexps {
+		alternatives = append(alternatives, "(?:"+regex.String()+")")
+	}
+
+	for _, matchString := range matchStrings {
+		alternatives = append(alternatives, stringPattern(stringMatchMode, matchString))
+	}
+
+	return regexp.Compile(strings.Join(alternatives, "|"))
+}
+
+// buildCombined combines the regexps, and the strings if includeStringsInCombined is set (and string matching is not disabled).
+func (blockUrls *traefik_block_regex_urls) buildCombined(regexps []*regexp.Regexp, matchStrings []string) (*regexp.Regexp, error) {
+	if !blockUrls.includeStringsInCombined || blockUrls.disableStringMatch {
+		matchStrings = nil
+	}
+
+	return combineRegex(regexps, matchStrings, blockUrls.stringMatchMode)
+}
+
+// identifyCombinedMatch finds which pattern made the combined regex match, so the block reason and pattern
+// stay the same as without combining. Only runs on a match, which is rare compared to clean requests.
+func (blockUrls *traefik_block_regex_urls) identifyCombinedMatch(request *http.Request, target string, regexps []*regexp.Regexp, matchStrings []string) *match {
+	if blockUrls.includeStringsInCombined {
+		for _, matchString := range matchStrings {
+			if blockUrls.matchesString(target, matchString) {
+				return &match{reason: "string match", url: fullURL(request), pattern: matchString}
+			}
+		}
+	}
+
+	for _, regex := range regexps {
+		if regex.MatchString(target) {
+			return &match{reason: "regex match", url: fullURL(request), pattern: regex.String()}
+		}
+	}
+
+	return &match{reason: "combined match", url: fullURL(request)}
+}
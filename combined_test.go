@@ -0,0 +1,68 @@
+package traefik_block_regex_urls_test
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+func Test_BlockUrls_CombineRegex_MatchesLikeSeparateRegex(t *testing.T) {
+	for _, includeStrings := range []bool{false, true} {
+		cfg := BlockUrls.CreateConfig()
+		cfg.MatchScope = "path"
+		cfg.Regex = []string{"^/wp-(login|admin)", `(?i)\.env$`, `^/cgi-bin/.*\.sh$`}
+		cfg.Strings = []string{"/phpmyadmin", "(x)"}
+		cfg.CombineRegex = true
+		cfg.IncludeStringsInCombined = includeStrings
+
+		handler := newHandler(t, cfg)
+
+		tests := map[string]int{
+			"http://localhost/wp-login.php":        http.StatusForbidden,
+			"http://localhost/app/.ENV":            http.StatusForbidden,
+			"http://localhost/cgi-bin/test.sh":     http.StatusForbidden,
+			"http://localhost/phpmyadmin/index":    http.StatusForbidden,
+			"http://localhost/files/(x).txt":       http.StatusForbidden,
+			"http://localhost/files/x.txt":         http.StatusOK,
+			"http://localhost/blog/wp-login":       http.StatusOK,
+			"http://localhost/cgi-bin/test.sh.txt": http.StatusOK,
+		}
+
+		for url, expected := range tests {
+			response := serveRequest(t, handler, url)
+			if response.StatusCode != expected {
+				t.Errorf("includeStrings=%t %s: unexpected status code %d, expected %d", includeStrings, url, response.StatusCode, expected)
+			}
+		}
+	}
+}
+
+func Test_BlockUrls_CombineRegex_KeepsReasonAndPattern(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.MatchScope = "path"
+	cfg.Regex = []string{"^/wp-login"}
+	cfg.Strings = []string{"/phpmyadmin"}
+	cfg.CombineRegex = true
+	cfg.IncludeStringsInCombined = true
+	cfg.ReasonHeader = "X-Block-Reason"
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	handler := newHandler(t, cfg)
+
+	serveRequest(t, handler, "http://localhost/wp-login")
+	serveRequest(t, handler, "http://localhost/phpmyadmin")
+
+	output := buf.String()
+	if !strings.Contains(output, "URL is blocked (regex match): (localhost/wp-login)") ||
+		!strings.Contains(output, "URL is blocked (string match): (localhost/phpmyadmin)") {
+		t.Errorf("unexpected log output %q", output)
+	}
+}
@@ -0,0 +1,23 @@
+package traefik_block_regex_urls
+
+import (
+	"net/http"
+	"strings"
+)
+
+// defaultCompositeFormat is used when CompositeRegex is set without a CompositeFormat.
+const defaultCompositeFormat = "{method} {path} {ua}"
+
+// renderComposite replaces the tokens of the format with the request values.
+// Supported tokens: {method}, {host}, {path}, {query} and {ua}.
+func renderComposite(format string, request *http.Request) string {
+	replacer := strings.NewReplacer(
+		"{method}", request.Method,
+		"{host}", request.Host,
+		"{path}", request.URL.Path,
+		"{query}", request.URL.RawQuery,
+		"{ua}", request.UserAgent(),
+	)
+
+	return replacer.Replace(format)
+}
@@ -0,0 +1,54 @@
+package traefik_block_regex_urls_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+func Test_BlockUrls_CompositeRegex(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.CompositeRegex = []string{`^POST /wp-login\.php (?i)python-requests`}
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	tests := []struct {
+		method    string
+		userAgent string
+		expected  int
+	}{
+		{http.MethodPost, "python-requests/2.31", http.StatusNotFound},
+		{http.MethodGet, "python-requests/2.31", http.StatusOK},
+		{http.MethodPost, "Mozilla/5.0", http.StatusOK},
+	}
+
+	for _, test := range tests {
+		req, err := http.NewRequestWithContext(context.Background(), test.method, "http://localhost/wp-login.php", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("User-Agent", test.userAgent)
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+
+		assertStatusCode(t, recorder.Result(), test.expected)
+	}
+}
+
+func Test_BlockUrls_CompositeFormat(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.CompositeFormat = "{host}|{path}|{query}"
+	cfg.CompositeRegex = []string{`^admin\.localhost\|/setup\|step=1$`}
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://admin.localhost/setup?step=1"), http.StatusNotFound)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/setup?step=1"), http.StatusOK)
+}
@@ -0,0 +1,58 @@
+package traefik_block_regex_urls
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strings"
+)
+
+// defaultGzipMinBytes is the body size from which block bodies are compressed with gzipBlockBody.
+const defaultGzipMinBytes = 1024
+
+// writeBody writes a block or decoy response like writeResponse, gzip compressed with gzipBlockBody when the body
+// reaches gzipMinBytes and the client accepts gzip. Otherwise, or if compression fails, the body is sent as is.
+func (blockUrls *traefik_block_regex_urls) writeBody(responseWriter http.ResponseWriter, request *http.Request, statusCode int, contentType string, body string) {
+	if !blockUrls.gzipBlockBody || body == "" || !bodyAllowed(statusCode) {
+		writeResponse(responseWriter, statusCode, contentType, body)
+		return
+	}
+
+	// the body depends on the Accept-Encoding header, also when it is not compressed
+	responseWriter.Header().Add("Vary", "Accept-Encoding")
+
+	if len(body) < blockUrls.gzipMinBytes || !acceptsGzip(request.Header.Get("Accept-Encoding")) {
+		writeResponse(responseWriter, statusCode, contentType, body)
+		return
+	}
+
+	var compressed bytes.Buffer
+
+	writer := gzip.NewWriter(&compressed)
+	if _, writeError := writer.Write([]byte(body)); writeError != nil || writer.Close() != nil {
+		writeResponse(responseWriter, statusCode, contentType, body)
+		return
+	}
+
+	responseWriter.Header().Set("Content-Encoding", "gzip")
+	writeResponse(responseWriter, statusCode, contentType, compressed.String())
+}
+
+// acceptsGzip reports whether the Accept-Encoding header accepts gzip with a non-zero quality,
+// named explicitly or through a "*" wildcard.
+func acceptsGzip(acceptEncoding string) bool {
+	wildcard := false
+
+	for _, coding := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(coding, ";")
+
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "gzip", "x-gzip":
+			return parseQuality(params) > 0
+		case "*":
+			wildcard = parseQuality(params) > 0
+		}
+	}
+
+	return wildcard
+}
@@ -0,0 +1,60 @@
+package traefik_block_regex_urls
+
+import (
+	"net/http"
+	"time"
+)
+
+// defaultMaxConcurrentWait is how long a request waits for an evaluation slot before it is shed.
+const defaultMaxConcurrentWait = 10 * time.Millisecond
+
+// concurrencyLimit is a semaphore bounding the requests under evaluation.
+type concurrencyLimit struct {
+	slots      chan struct{}
+	wait       time.Duration
+	statusCode int
+}
+
+func newConcurrencyLimit(maxConcurrent int, wait time.Duration, statusCode int) *concurrencyLimit {
+	return &concurrencyLimit{slots: make(chan struct{}, maxConcurrent), wait: wait, statusCode: statusCode}
+}
+
+// acquire takes a slot, waiting at most the configured wait. Returns false if no slot became available.
+func (limit *concurrencyLimit) acquire() bool {
+	select {
+	case limit.slots <- struct{}{}:
+		return true
+	default:
+	}
+
+	timer := time.NewTimer(limit.wait)
+	defer timer.Stop()
+
+	select {
+	case limit.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+func (limit *concurrencyLimit) release() {
+	<-limit.slots
+}
+
+// evaluateLimited runs the shadow and block rules within a concurrency slot, if a limit is configured.
+// Returns false if the request was not evaluated because no slot became available.
+func (blockUrls *traefik_block_regex_urls) evaluateLimited(request *http.Request, client *requestClient) (*match, bool) {
+	if blockUrls.concurrencyLimit != nil {
+		if !blockUrls.concurrencyLimit.acquire() {
+			return nil, false
+		}
+		defer blockUrls.concurrencyLimit.release()
+	}
+
+	if blockUrls.shadowRules != nil {
+		blockUrls.evaluateShadow(request)
+	}
+
+	return blockUrls.evaluate(request, client), true
+}
@@ -0,0 +1,63 @@
+package traefik_block_regex_urls_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+func Test_BlockUrls_MaxConcurrent_ShedsOverflowRequest(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.MaxConcurrent = 1
+	cfg.MaxConcurrentWait = "1ms"
+
+	entered := make(chan struct{})
+	unblock := make(chan struct{})
+	slowMatcher := BlockUrls.MatcherFunc(func(req *http.Request) (bool, string) {
+		if req.URL.Path == "/slow" {
+			close(entered)
+			<-unblock
+		}
+
+		return false, ""
+	})
+
+	handler, err := BlockUrls.NewWithOptions(context.Background(), nil, cfg, "BlockUrls", BlockUrls.WithMatchers(slowMatcher))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan int)
+	go func() {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "http://localhost/slow", nil))
+		done <- recorder.Result().StatusCode
+	}()
+
+	<-entered
+
+	response := serveRequest(t, handler, "http://localhost/overflow")
+	assertStatusCode(t, response, http.StatusServiceUnavailable)
+
+	close(unblock)
+	if statusCode := <-done; statusCode != http.StatusOK {
+		t.Errorf("unexpected status code %d for the slow request", statusCode)
+	}
+
+	response = serveRequest(t, handler, "http://localhost/after")
+	assertStatusCode(t, response, http.StatusOK)
+}
+
+func Test_BlockUrls_MaxConcurrent_InvalidWaitFails(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.MaxConcurrent = 1
+	cfg.MaxConcurrentWait = "soon"
+
+	_, err := BlockUrls.New(context.Background(), nil, cfg, "BlockUrls")
+	if err == nil {
+		t.Fatal("expected an error for an invalid maxConcurrentWait")
+	}
+}
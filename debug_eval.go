@@ -0,0 +1,75 @@
+package traefik_block_regex_urls
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// maxDebugEvalBodyBytes bounds the body read by the debug eval endpoint, which only holds a url.
+const maxDebugEvalBodyBytes = 8192
+
+// debugEvalResult is the document served on the debug eval path.
+type debugEvalResult struct {
+	URL     string `json:"url"`
+	Blocked bool   `json:"blocked"`
+	Reason  string `json:"reason,omitempty"`
+	Pattern string `json:"pattern,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// IsBlocked evaluates a url (e.g. "http://example.com/wp-login.php") against the current rules, as a bare GET
+// request without headers or client ip. Returns whether it is blocked and the matched reason and pattern.
+// The evaluation counts nothing, rate limited rules report whether the url would exceed their limit,
+// and the header checks as well as minInterval and distinctURLThreshold do not apply.
+func (blockUrls *traefik_block_regex_urls) IsBlocked(rawURL string) (bool, string, string, error) {
+	request, requestError := http.NewRequest(http.MethodGet, rawURL, nil)
+	if requestError != nil {
+		return false, "", "", requestError
+	}
+
+	blockMatch := blockUrls.evaluateWith(request, evaluation{dryRun: true, urlOnly: true})
+	if blockMatch == nil {
+		return false, "", "", nil
+	}
+
+	return true, blockMatch.reason, blockMatch.pattern, nil
+}
+
+// serveDebugEval evaluates the url given in the url query parameter, or in the body of a POST, and writes the verdict.
+func (blockUrls *traefik_block_regex_urls) serveDebugEval(responseWriter http.ResponseWriter, request *http.Request) {
+	rawURL := request.URL.Query().Get("url")
+	if rawURL == "" && request.Method == http.MethodPost {
+		body, readError := io.ReadAll(io.LimitReader(request.Body, maxDebugEvalBodyBytes))
+		if readError == nil {
+			rawURL = strings.TrimSpace(string(body))
+		}
+	}
+
+	result := debugEvalResult{URL: rawURL}
+	statusCode := http.StatusOK
+
+	if rawURL == "" {
+		result.Error = "missing url"
+		statusCode = http.StatusBadRequest
+	} else {
+		blocked, reason, pattern, evalError := blockUrls.IsBlocked(rawURL)
+		if evalError != nil {
+			result.Error = evalError.Error()
+			statusCode = http.StatusBadRequest
+		}
+
+		result.Blocked, result.Reason, result.Pattern = blocked, reason, pattern
+	}
+
+	body, marshalError := json.Marshal(result)
+	if marshalError != nil {
+		blockUrls.logger.Printf("error encoding debug eval result: %v middleware=%s", marshalError, blockUrls.name)
+		responseWriter.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	responseWriter.Header().Set("Cache-Control", "no-store")
+	writeResponse(responseWriter, statusCode, "application/json", string(body))
+}
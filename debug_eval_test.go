@@ -0,0 +1,142 @@
+package traefik_block_regex_urls_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+type debugEvalResult struct {
+	URL     string `json:"url"`
+	Blocked bool   `json:"blocked"`
+	Reason  string `json:"reason"`
+	Pattern string `json:"pattern"`
+	Error   string `json:"error"`
+}
+
+func decodeDebugEvalResult(t *testing.T, response *http.Response) debugEvalResult {
+	t.Helper()
+
+	var result debugEvalResult
+	if err := json.NewDecoder(response.Body).Decode(&result); err != nil {
+		t.Fatal(err)
+	}
+
+	return result
+}
+
+func Test_BlockUrls_DebugEvalPath_ReturnsVerdict(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login"}
+	cfg.DebugEvalPath = "/__block_eval"
+	cfg.AllowedIPs = []string{"2.56.20.0/24"}
+
+	handler := newHandler(t, cfg)
+	allowedClient := map[string]string{"X-Forwarded-For": "2.56.20.1"}
+
+	response := serveRequestWithHeaders(t, handler, "http://localhost/__block_eval?url="+url.QueryEscape("http://example.com/wp-login.php"), allowedClient)
+	assertStatusCode(t, response, http.StatusOK)
+
+	result := decodeDebugEvalResult(t, response)
+	if !result.Blocked || result.Reason != "regex match" || result.Pattern != "(.*)/wp-login" {
+		t.Errorf("unexpected result for a blocked url: %+v", result)
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, "http://localhost/__block_eval", strings.NewReader("http://example.com/index.html\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-Forwarded-For", "2.56.20.1")
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+
+	result = decodeDebugEvalResult(t, recorder.Result())
+	if result.Blocked || result.URL != "http://example.com/index.html" {
+		t.Errorf("unexpected result for an allowed url: %+v", result)
+	}
+
+	response = serveRequestWithHeaders(t, handler, "http://localhost/__block_eval", allowedClient)
+	assertStatusCode(t, response, http.StatusBadRequest)
+}
+
+func Test_BlockUrls_DebugEvalPath_IsPassedOn_IfNotAllowed(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login"}
+	cfg.DebugEvalPath = "/__block_eval"
+	cfg.AllowedIPs = []string{"2.56.20.0/24"}
+
+	handler := newHandler(t, cfg)
+
+	response := serveRequestWithHeaders(t, handler, "http://localhost/__block_eval?url=http://example.com/index.html", map[string]string{"X-Forwarded-For": "198.51.100.1"})
+	assertStatusCode(t, response, http.StatusOK)
+
+	if contentType := response.Header.Get("Content-Type"); contentType == "application/json" {
+		t.Error("the debug eval path was served to a client outside the allowlist")
+	}
+}
+
+func Test_BlockUrls_DebugEvalPath_IsDisabled_WithoutAllowedIPs(t *testing.T) {
+	var output bytes.Buffer
+
+	log.SetOutput(&output)
+	defer log.SetOutput(os.Stderr)
+
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login"}
+	cfg.DebugEvalPath = "/__block_eval"
+
+	handler := newHandler(t, cfg)
+
+	if !strings.Contains(output.String(), "Warning: debugEvalPath without allowedIPs is disabled") {
+		t.Errorf("expected a warning, got %q", output.String())
+	}
+
+	// a spoofed private ip does not get a verdict
+	response := serveRequestWithHeaders(t, handler, "http://localhost/__block_eval?url=http://example.com/index.html", map[string]string{"X-Forwarded-For": "10.0.0.1"})
+	assertStatusCode(t, response, http.StatusOK)
+
+	if contentType := response.Header.Get("Content-Type"); contentType == "application/json" {
+		t.Error("the debug eval path was served without allowedIPs")
+	}
+}
+
+func Test_BlockUrls_DebugEvalPath_CountsNothing(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.MatchScope = "path"
+	cfg.Rules = []BlockUrls.Rule{{Regex: "^/api/password-reset", StatusCode: 429, RateLimit: 1}}
+	cfg.BlockMissingAccept = true
+	cfg.DebugEvalPath = "/__block_eval"
+	cfg.AllowedIPs = []string{"2.56.20.0/24"}
+
+	handler := newHandler(t, cfg)
+	allowedClient := map[string]string{"X-Forwarded-For": "2.56.20.1", "Accept": "application/json"}
+
+	for i := 0; i < 3; i++ {
+		response := serveRequestWithHeaders(t, handler, "http://localhost/__block_eval?url="+url.QueryEscape("http://example.com/api/password-reset"), allowedClient)
+
+		if result := decodeDebugEvalResult(t, response); result.Blocked {
+			t.Fatalf("expected the probe to stay below the rate limit and skip the accept check, got %+v", result)
+		}
+	}
+
+	client := map[string]string{"X-Forwarded-For": "198.51.100.1", "Accept": "text/html"}
+
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/api/password-reset", client), http.StatusOK)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/api/password-reset", client), http.StatusTooManyRequests)
+
+	// a dry probe reports the exceeded limit
+	response := serveRequestWithHeaders(t, handler, "http://localhost/__block_eval?url="+url.QueryEscape("http://example.com/api/password-reset"), allowedClient)
+	if result := decodeDebugEvalResult(t, response); !result.Blocked || result.Reason != "rule match" {
+		t.Errorf("expected the probe to report the exceeded rate limit, got %+v", result)
+	}
+}
@@ -0,0 +1,73 @@
+package traefik_block_regex_urls
+
+import (
+	"context"
+	"net/http"
+)
+
+// Decision outcomes of a matched request.
+const (
+	DecisionBlocked = "blocked"
+	DecisionTagged  = "tagged"
+	DecisionAllowed = "allowed"
+)
+
+// Decision is the outcome of a matched request, attached to the request context for tracing integrations.
+// Requests which match no rule carry no decision.
+type Decision struct {
+	Outcome string
+	Reason  string
+	Pattern string
+}
+
+type decisionContextKey struct{}
+
+// ContextWithDecision returns a context in which the plugin records its decision, for middlewares wrapping the
+// plugin: blocked requests are not passed on, so the decision can only be read back through this context.
+// Requests passed on carry the decision in their context anyway.
+func ContextWithDecision(ctx context.Context) context.Context {
+	return context.WithValue(ctx, decisionContextKey{}, &Decision{})
+}
+
+// DecisionFromContext returns the decision recorded in the context, or false if there is none.
+func DecisionFromContext(ctx context.Context) (Decision, bool) {
+	decision, found := ctx.Value(decisionContextKey{}).(*Decision)
+	if !found || decision.Outcome == "" {
+		return Decision{}, false
+	}
+
+	return *decision, true
+}
+
+// DecisionAttributes returns the decision recorded in the context as span attributes, e.g. for OpenTelemetry.
+// Returns nil if there is no decision.
+func DecisionAttributes(ctx context.Context) map[string]string {
+	decision, found := DecisionFromContext(ctx)
+	if !found {
+		return nil
+	}
+
+	attributes := map[string]string{
+		"block_regex_urls.outcome": decision.Outcome,
+		"block_regex_urls.reason":  decision.Reason,
+	}
+
+	if decision.Pattern != "" {
+		attributes["block_regex_urls.pattern"] = decision.Pattern
+	}
+
+	return attributes
+}
+
+// recordDecision stores the decision in the context holder of a wrapping middleware, if any,
+// otherwise returns the request with the decision attached to its context.
+func recordDecision(request *http.Request, outcome string, blockMatch *match) *http.Request {
+	decision := Decision{Outcome: outcome, Reason: blockMatch.reason, Pattern: blockMatch.pattern}
+
+	if holder, found := request.Context().Value(decisionContextKey{}).(*Decision); found {
+		*holder = decision
+		return request
+	}
+
+	return request.WithContext(context.WithValue(request.Context(), decisionContextKey{}, &decision))
+}
@@ -0,0 +1,69 @@
+package traefik_block_regex_urls_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+func Test_BlockUrls_Decision_ReadByWrappingMiddleware(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login"}
+
+	handler := newHandler(t, cfg)
+
+	var attributes map[string]string
+
+	tracing := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		ctx := BlockUrls.ContextWithDecision(req.Context())
+		handler.ServeHTTP(rw, req.WithContext(ctx))
+		attributes = BlockUrls.DecisionAttributes(ctx)
+	})
+
+	response := serveRequest(t, tracing, "http://localhost/wp-login")
+	assertStatusCode(t, response, http.StatusForbidden)
+
+	expected := map[string]string{
+		"block_regex_urls.outcome": "blocked",
+		"block_regex_urls.reason":  "regex match",
+		"block_regex_urls.pattern": "(.*)/wp-login",
+	}
+	if !reflect.DeepEqual(attributes, expected) {
+		t.Errorf("unexpected attributes %v", attributes)
+	}
+
+	serveRequest(t, tracing, "http://localhost/index.html")
+
+	if attributes != nil {
+		t.Errorf("expected no attributes for an allowed request, got %v", attributes)
+	}
+}
+
+func Test_BlockUrls_Decision_PassedDownstream(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login"}
+	cfg.Mode = "tag"
+
+	var decision BlockUrls.Decision
+	var found bool
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		decision, found = BlockUrls.DecisionFromContext(req.Context())
+	})
+
+	handler, err := BlockUrls.New(context.Background(), next, cfg, "BlockUrls")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "http://localhost/wp-login", nil))
+
+	expected := BlockUrls.Decision{Outcome: "tagged", Reason: "regex match", Pattern: "(.*)/wp-login"}
+	if !found || decision != expected {
+		t.Errorf("unexpected decision %v (found %t)", decision, found)
+	}
+}
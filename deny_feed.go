@@ -0,0 +1,110 @@
+package traefik_block_regex_urls
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"time"
+)
+
+// defaultDenyFeedRefreshInterval is how often the deny feed is fetched when no interval is configured.
+const defaultDenyFeedRefreshInterval = time.Hour
+
+// denyFeedTimeout bounds a single fetch of the deny feed.
+const denyFeedTimeout = 30 * time.Second
+
+// fetchDenyFeed downloads the feed and parses one ip or CIDR per line, ignoring blanks and comments.
+func fetchDenyFeed(ctx context.Context, client *http.Client, feedURL string) ([]*net.IPNet, error) {
+	ctx, cancel := context.WithTimeout(ctx, denyFeedTimeout)
+	defer cancel()
+
+	request, requestError := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
+	if requestError != nil {
+		return nil, requestError
+	}
+
+	response, responseError := client.Do(request)
+	if responseError != nil {
+		return nil, responseError
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		_, _ = io.Copy(io.Discard, response.Body)
+		return nil, fmt.Errorf("unexpected status code %d", response.StatusCode)
+	}
+
+	entries, readError := parsePatternLines(response.Body)
+	if readError != nil {
+		return nil, readError
+	}
+
+	return parseIPNets(entries)
+}
+
+// watchDenyFeed fetches the deny feed right away and then every interval, until ctx is done.
+// Failed fetches are logged and keep the last good list.
+func (blockUrls *traefik_block_regex_urls) watchDenyFeed(ctx context.Context, feedURL string, interval time.Duration) {
+	client := &http.Client{}
+
+	refresh := func() {
+		denyList, fetchError := fetchDenyFeed(ctx, client, feedURL)
+		if fetchError != nil {
+			blockUrls.logger.Printf("error fetching deny feed %q, keeping the last good list: %v middleware=%s", feedURL, fetchError, blockUrls.name)
+			return
+		}
+
+		blockUrls.mu.Lock()
+
+		kept, limitError := blockUrls.fitMaxRules(len(denyList), blockUrls.loadedPatterns()-len(blockUrls.denyFeed))
+		if limitError == nil {
+			blockUrls.denyFeed = denyList[:kept]
+		}
+
+		blockUrls.mu.Unlock()
+
+		if limitError != nil {
+			blockUrls.logger.Printf("error refreshing deny feed %q, keeping the last good list: %v middleware=%s", feedURL, limitError, blockUrls.name)
+			return
+		}
+
+		if kept < len(denyList) {
+			blockUrls.logger.Printf("Truncated deny feed %q from %d to %d entries to fit maxRules %d: middleware=%s", feedURL, len(denyList), kept, blockUrls.maxRules, blockUrls.name)
+		}
+
+		if !blockUrls.silentStartUp {
+			blockUrls.logger.Printf("Refreshed deny feed %q (%d entries): middleware=%s", feedURL, kept, blockUrls.name)
+		}
+	}
+
+	refresh()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			refresh()
+		}
+	}
+}
+
+// isDeniedByFeed reports whether the client, the first collected remote ip, is in the current deny feed.
+func (blockUrls *traefik_block_regex_urls) isDeniedByFeed(client *requestClient) bool {
+	blockUrls.mu.RLock()
+	denyFeed := blockUrls.denyFeed
+	blockUrls.mu.RUnlock()
+
+	if len(denyFeed) == 0 {
+		return false
+	}
+
+	remoteIP := client.remoteIP()
+
+	return remoteIP != nil && containsIP(denyFeed, remoteIP)
+}
@@ -0,0 +1,88 @@
+package traefik_block_regex_urls_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+type feedServer struct {
+	mu         sync.Mutex
+	body       string
+	statusCode int
+}
+
+func (feed *feedServer) set(statusCode int, body string) {
+	feed.mu.Lock()
+	defer feed.mu.Unlock()
+
+	feed.statusCode, feed.body = statusCode, body
+}
+
+func (feed *feedServer) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	feed.mu.Lock()
+	defer feed.mu.Unlock()
+
+	rw.WriteHeader(feed.statusCode)
+	_, _ = rw.Write([]byte(feed.body))
+}
+
+func Test_BlockUrls_DenyFeed_SwapsListOnRefresh(t *testing.T) {
+	feed := &feedServer{}
+	feed.set(http.StatusOK, "# threat intel\n2.56.20.0/24\n\n198.51.100.7\n")
+
+	server := httptest.NewServer(feed)
+	defer server.Close()
+
+	cfg := BlockUrls.CreateConfig()
+	cfg.DenyFeedURL = server.URL
+	cfg.DenyFeedRefreshInterval = "10ms"
+	cfg.StatusCode = 404
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	handler, err := BlockUrls.New(ctx, next, cfg, "BlockUrls")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	statusFor := func(ip string) int {
+		return serveRequestWithHeaders(t, handler, "http://localhost/", map[string]string{"X-Forwarded-For": ip}).StatusCode
+	}
+
+	waitFor(t, func() bool { return statusFor("2.56.20.1") == http.StatusNotFound })
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/", map[string]string{"X-Forwarded-For": "198.51.100.7"}), http.StatusNotFound)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/", map[string]string{"X-Forwarded-For": "8.8.8.8"}), http.StatusOK)
+
+	// a failing fetch keeps the last good list
+	feed.set(http.StatusInternalServerError, "")
+	time.Sleep(50 * time.Millisecond)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/", map[string]string{"X-Forwarded-For": "2.56.20.1"}), http.StatusNotFound)
+
+	feed.set(http.StatusOK, "8.8.8.0/24\n")
+	waitFor(t, func() bool { return statusFor("8.8.8.8") == http.StatusNotFound })
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/", map[string]string{"X-Forwarded-For": "2.56.20.1"}), http.StatusOK)
+}
+
+// waitFor polls the condition until it is true, failing the test after two seconds.
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(2 * time.Second)
+
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+
+		time.Sleep(5 * time.Millisecond)
+	}
+}
@@ -0,0 +1,135 @@
+package traefik_block_regex_urls
+
+import (
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// defaultDistinctURLWindow is the window of distinctURLThreshold without an explicit one.
+const defaultDistinctURLWindow = time.Minute
+
+// defaultDistinctURLMaxEntries bounds the number of tracked client ips, which a client can rotate unless the client ip
+// comes from forwardedIPDepth or trustedIPHeader.
+const defaultDistinctURLMaxEntries = 10000
+
+// distinctURLTracker counts the distinct paths requested by each client ip in a fixed window, to spot scanners
+// probing many urls. Paths are kept as hashes, at most threshold + 1 per ip, and at most maxEntries ips are tracked,
+// so memory stays bounded. When full, the ip whose window started first is forgotten first.
+type distinctURLTracker struct {
+	mu         sync.Mutex
+	threshold  int
+	window     time.Duration
+	maxEntries int
+	clients    map[string]*distinctURLs
+	// order holds the ips in the order their windows started, entries of ips which started a new window are stale.
+	order []distinctURLEntry
+}
+
+// distinctURLs are the path hashes of a client ip in its current window.
+type distinctURLs struct {
+	windowStart time.Time
+	seen        map[uint64]struct{}
+}
+
+// distinctURLEntry is an ip and the start of its window.
+type distinctURLEntry struct {
+	ip          string
+	windowStart time.Time
+}
+
+func newDistinctURLTracker(threshold int, window time.Duration, maxEntries int) *distinctURLTracker {
+	return &distinctURLTracker{
+		threshold:  threshold,
+		window:     window,
+		maxEntries: maxEntries,
+		clients:    map[string]*distinctURLs{},
+	}
+}
+
+// exceeded records the path for the ip and reports whether the ip requested more than threshold distinct paths
+// in its current window. Requests without a known client ip are never counted.
+func (tracker *distinctURLTracker) exceeded(ip string, path string, now time.Time) bool {
+	if ip == "" {
+		return false
+	}
+
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	tracker.removeExpired(now)
+
+	client, found := tracker.clients[ip]
+	if !found || now.Sub(client.windowStart) >= tracker.window {
+		client = tracker.startWindow(ip, now)
+	}
+
+	if len(client.seen) > tracker.threshold {
+		return true
+	}
+
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(path))
+	client.seen[hash.Sum64()] = struct{}{}
+
+	return len(client.seen) > tracker.threshold
+}
+
+// startWindow starts a new window for the ip, forgetting the oldest windows beyond maxEntries.
+// The caller must hold the lock.
+func (tracker *distinctURLTracker) startWindow(ip string, now time.Time) *distinctURLs {
+	client := &distinctURLs{windowStart: now, seen: map[uint64]struct{}{}}
+	tracker.clients[ip] = client
+	tracker.order = append(tracker.order, distinctURLEntry{ip: ip, windowStart: now})
+
+	for len(tracker.clients) > tracker.maxEntries {
+		tracker.forgetOldest()
+	}
+
+	// stale entries pile up when ips start new windows, drop them once they outnumber the live ones
+	if len(tracker.order) > 2*len(tracker.clients)+1 {
+		live := make([]distinctURLEntry, 0, len(tracker.clients))
+		for _, entry := range tracker.order {
+			if tracker.isLive(entry) {
+				live = append(live, entry)
+			}
+		}
+
+		tracker.order = live
+	}
+
+	return client
+}
+
+// forgetOldest forgets the ip whose window started first. The caller must hold the lock.
+func (tracker *distinctURLTracker) forgetOldest() {
+	for len(tracker.order) > 0 {
+		entry := tracker.order[0]
+		tracker.order = tracker.order[1:]
+
+		if tracker.isLive(entry) {
+			delete(tracker.clients, entry.ip)
+			return
+		}
+	}
+}
+
+// isLive reports whether the entry is the current window of its ip. The caller must hold the lock.
+func (tracker *distinctURLTracker) isLive(entry distinctURLEntry) bool {
+	client, found := tracker.clients[entry.ip]
+
+	return found && client.windowStart.Equal(entry.windowStart)
+}
+
+// removeExpired drops the ips whose window ended, which are the oldest ones.
+// The caller must hold the lock.
+func (tracker *distinctURLTracker) removeExpired(now time.Time) {
+	for len(tracker.order) > 0 && now.Sub(tracker.order[0].windowStart) >= tracker.window {
+		entry := tracker.order[0]
+		tracker.order = tracker.order[1:]
+
+		if tracker.isLive(entry) {
+			delete(tracker.clients, entry.ip)
+		}
+	}
+}
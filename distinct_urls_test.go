@@ -0,0 +1,71 @@
+package traefik_block_regex_urls_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+func Test_BlockUrls_DistinctURLThreshold_BlocksScanners(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.DistinctURLThreshold = 20
+	cfg.DistinctURLWindow = "1m"
+
+	clock := newFakeClock()
+
+	handler, err := BlockUrls.NewWithOptions(context.Background(), nil, cfg, "BlockUrls", BlockUrls.WithClock(clock.Now))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scanner := map[string]string{"X-Forwarded-For": "203.0.113.1"}
+	visitor := map[string]string{"X-Forwarded-For": "203.0.113.2"}
+
+	for index := 1; index <= 20; index++ {
+		assertStatusCode(t, serveRequestWithHeaders(t, handler, fmt.Sprintf("http://localhost/probe-%d", index), scanner), http.StatusOK)
+	}
+
+	// the 21st distinct url exceeds the threshold, then every request of the ip is blocked for the window
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/probe-21", scanner), http.StatusForbidden)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/probe-1", scanner), http.StatusForbidden)
+
+	// repeats of the same url and other ips are not counted
+	for index := 0; index < 50; index++ {
+		assertStatusCode(t, serveRequestWithHeaders(t, handler, fmt.Sprintf("http://localhost/home?v=%d", index), visitor), http.StatusOK)
+	}
+
+	clock.Advance(time.Minute)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/probe-22", scanner), http.StatusOK)
+}
+
+func Test_BlockUrls_DistinctURLThreshold_ForgetsOldestBeyondMaxEntries(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.DistinctURLThreshold = 1
+	cfg.DistinctURLMaxEntries = 1
+
+	handler := newHandler(t, cfg)
+
+	first := map[string]string{"X-Forwarded-For": "203.0.113.1"}
+	second := map[string]string{"X-Forwarded-For": "203.0.113.2"}
+
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/a", first), http.StatusOK)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/b", first), http.StatusForbidden)
+
+	// the second ip takes the only entry, the first one starts over
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/a", second), http.StatusOK)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/c", first), http.StatusOK)
+}
+
+func Test_BlockUrls_DistinctURLWindow_ReturnsError_IfInvalid(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.DistinctURLThreshold = 20
+	cfg.DistinctURLWindow = "soon"
+
+	if _, err := BlockUrls.New(context.Background(), nil, cfg, "BlockUrls"); err == nil {
+		t.Fatal("expected an error for an invalid distinctURLWindow")
+	}
+}
@@ -0,0 +1,40 @@
+package traefik_block_regex_urls
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// blockEcho is the body of a block response with echoOnBlock: what the plugin saw of the blocked request.
+type blockEcho struct {
+	Method  string `json:"method"`
+	Host    string `json:"host"`
+	Path    string `json:"path"`
+	Query   string `json:"query"`
+	IP      string `json:"ip"`
+	Reason  string `json:"reason"`
+	Pattern string `json:"pattern,omitempty"`
+	Status  int    `json:"status"`
+}
+
+// writeEcho writes the block status with a JSON echo of the blocked request as body, to debug why a rule fired.
+func (blockUrls *traefik_block_regex_urls) writeEcho(responseWriter http.ResponseWriter, request *http.Request, blockMatch *match, statusCode int) {
+	body, marshalError := json.Marshal(blockEcho{
+		Method:  request.Method,
+		Host:    request.Host,
+		Path:    request.URL.Path,
+		Query:   request.URL.RawQuery,
+		IP:      blockMatch.clientIP,
+		Reason:  blockMatch.reason,
+		Pattern: blockMatch.pattern,
+		Status:  statusCode,
+	})
+	if marshalError != nil {
+		blockUrls.logger.Printf("error encoding block echo: %v middleware=%s", marshalError, blockUrls.name)
+		writeResponse(responseWriter, statusCode, "", "")
+		return
+	}
+
+	responseWriter.Header().Set("Cache-Control", "no-store")
+	writeResponse(responseWriter, statusCode, "application/json", string(body))
+}
@@ -0,0 +1,23 @@
+package traefik_block_regex_urls
+
+import "time"
+
+// BlockEvent describes a blocked request, sent to the events channel of embedders and kept by RecentBlocks.
+type BlockEvent struct {
+	// Middleware is the name of the plugin instance which blocked the request.
+	Middleware string    `json:"middleware"`
+	Time       time.Time `json:"time"`
+	IP         string    `json:"ip"`
+	URL        string    `json:"url"`
+	Reason     string    `json:"reason"`
+	Pattern    string    `json:"pattern,omitempty"`
+}
+
+// sendEvent sends the event without blocking the request.
+// When the channel is full, the event is dropped.
+func (blockUrls *traefik_block_regex_urls) sendEvent(event BlockEvent) {
+	select {
+	case blockUrls.events <- event:
+	default:
+	}
+}
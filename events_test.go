@@ -0,0 +1,54 @@
+package traefik_block_regex_urls_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+func Test_BlockUrls_WithEvents_SendsBlockEvents(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login"}
+	cfg.StatusCode = 404
+
+	clock := newFakeClock()
+	events := make(chan BlockUrls.BlockEvent, 1)
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	handler, err := BlockUrls.NewWithOptions(context.Background(), next, cfg, "BlockUrls",
+		BlockUrls.WithClock(clock.Now), BlockUrls.WithEvents(events))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := map[string]string{"X-Forwarded-For": "2.56.20.1"}
+
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/index.html", client), http.StatusOK)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/wp-login", client), http.StatusNotFound)
+
+	// the channel is full, the event is dropped and the request not held up
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/wp-login?again", client), http.StatusNotFound)
+
+	event := <-events
+
+	expected := BlockUrls.BlockEvent{
+		Middleware: "BlockUrls",
+		Time:       clock.Now(),
+		IP:         "2.56.20.1",
+		URL:        "localhost/wp-login",
+		Reason:     "regex match",
+		Pattern:    "(.*)/wp-login",
+	}
+
+	if event != expected {
+		t.Errorf("unexpected event: %+v", event)
+	}
+
+	select {
+	case event := <-events:
+		t.Errorf("expected the second event to be dropped, got %+v", event)
+	default:
+	}
+}
@@ -0,0 +1,85 @@
+package traefik_block_regex_urls
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+)
+
+// defaultFormMaxBytes caps the size of a form body parsed for formFieldRegex.
+const defaultFormMaxBytes = 64 * 1024
+
+// formField is a compiled formFieldRegex entry.
+type formField struct {
+	name    string
+	regexps []*regexp.Regexp
+}
+
+// compileFormFields compiles the regexps per field name, sorted by name so matches are deterministic.
+func compileFormFields(fieldRegex map[string][]string) ([]formField, error) {
+	formFields := make([]formField, 0, len(fieldRegex))
+
+	for name, regexList := range fieldRegex {
+		regexps, compileError := compileRegexList(regexList)
+		if compileError != nil {
+			return nil, fmt.Errorf("error in form field %q: %w", name, compileError)
+		}
+
+		formFields = append(formFields, formField{name: name, regexps: regexps})
+	}
+
+	sort.Slice(formFields, func(i, j int) bool { return formFields[i].name < formFields[j].name })
+
+	return formFields, nil
+}
+
+// replayBody is a request body whose already read part is replayed before the rest.
+type replayBody struct {
+	io.Reader
+	io.Closer
+}
+
+// matchFormFields matches the fields of an url-encoded form body against their regexps.
+// Bodies larger than formMaxBytes and multipart forms are not parsed. The read part of the body is
+// replayed, so the next handler gets the unchanged body. Returns the first match, or nil.
+func (blockUrls *traefik_block_regex_urls) matchFormFields(request *http.Request) *match {
+	if len(blockUrls.formFields) == 0 || request.Body == nil || request.Body == http.NoBody {
+		return nil
+	}
+
+	mediaType, _, parseError := mime.ParseMediaType(request.Header.Get("Content-Type"))
+	if parseError != nil || mediaType != "application/x-www-form-urlencoded" {
+		return nil
+	}
+
+	if request.ContentLength > int64(blockUrls.formMaxBytes) {
+		return nil
+	}
+
+	body, readError := io.ReadAll(io.LimitReader(request.Body, int64(blockUrls.formMaxBytes)+1))
+	request.Body = replayBody{Reader: io.MultiReader(bytes.NewReader(body), request.Body), Closer: request.Body}
+
+	if readError != nil || len(body) > blockUrls.formMaxBytes {
+		return nil
+	}
+
+	// a malformed pair is skipped, the others are still returned
+	values, _ := url.ParseQuery(string(body))
+
+	for _, field := range blockUrls.formFields {
+		for _, value := range values[field.name] {
+			for _, regex := range field.regexps {
+				if regex.MatchString(value) {
+					return &match{reason: "form field match", url: fullURL(request), pattern: field.name + ": " + regex.String()}
+				}
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package traefik_block_regex_urls_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+func Test_BlockUrls_FormFieldRegex(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.FormFieldRegex = map[string][]string{
+		"comment": {`(?i)viagra`},
+		"url":     {`\.ru/`},
+	}
+	cfg.StatusCode = 404
+
+	var upstreamBody string
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		upstreamBody = string(body)
+	})
+
+	handler, err := BlockUrls.New(context.Background(), next, cfg, "BlockUrls")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	postForm := func(contentType string, body string) *http.Response {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, "http://localhost/comments", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Content-Type", contentType)
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+
+		return recorder.Result()
+	}
+
+	form := "application/x-www-form-urlencoded"
+
+	assertStatusCode(t, postForm(form, "name=bob&comment=cheap+VIAGRA"), http.StatusNotFound)
+	assertStatusCode(t, postForm(form+"; charset=utf-8", "url=http%3A%2F%2Fspam.ru%2Fx"), http.StatusNotFound)
+	assertStatusCode(t, postForm(form, "name=viagra&comment=hello"), http.StatusOK)
+
+	if upstreamBody != "name=viagra&comment=hello" {
+		t.Errorf("expected the body to be passed on unchanged, got %q", upstreamBody)
+	}
+
+	assertStatusCode(t, postForm("text/plain", "comment=viagra"), http.StatusOK)
+	assertStatusCode(t, postForm(form, "comment=viagra&padding="+strings.Repeat("x", 64*1024)), http.StatusOK)
+
+	if !strings.HasPrefix(upstreamBody, "comment=viagra&padding=xxx") || len(upstreamBody) != len("comment=viagra&padding=")+64*1024 {
+		t.Errorf("expected an oversized body to be passed on unchanged, got %d bytes", len(upstreamBody))
+	}
+}
@@ -0,0 +1,91 @@
+package traefik_block_regex_urls
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// defaultCountryHeader carries the ISO 3166-1 alpha-2 country of the client, set by a GeoIP aware proxy.
+const defaultCountryHeader = "X-Country-Code"
+
+// GeoBlock blocks requests from the listed countries, by default with 451 Unavailable For Legal Reasons (RFC 7725).
+// The plugin does no GeoIP lookup itself: the country is read from a header set by a trusted proxy or GeoIP plugin.
+type GeoBlock struct {
+	// Countries are ISO 3166-1 alpha-2 codes, e.g. "DE", compared case-insensitively.
+	Countries []string `yaml:"countries"`
+	// CountryHeader defaults to X-Country-Code, e.g. CF-IPCountry behind Cloudflare.
+	CountryHeader string `yaml:"countryHeader,omitempty"`
+	StatusCode    int    `yaml:"statusCode,omitempty"`
+	// BlockedBy is the url of the authority requiring the block, sent as Link header with rel="blocked-by".
+	BlockedBy   string `yaml:"blockedBy,omitempty"`
+	Body        string `yaml:"body,omitempty"`
+	ContentType string `yaml:"contentType,omitempty"`
+}
+
+// geoBlock is a compiled GeoBlock.
+type geoBlock struct {
+	countries     []string
+	countryHeader string
+	response      *rule
+}
+
+// compileGeoBlock returns nil if there is no geo block.
+func compileGeoBlock(config *GeoBlock) (*geoBlock, error) {
+	if config == nil {
+		return nil, nil
+	}
+
+	if len(config.Countries) == 0 {
+		return nil, fmt.Errorf("error in geoBlock: no countries set")
+	}
+
+	countries := make([]string, len(config.Countries))
+	for index, country := range config.Countries {
+		countries[index] = strings.ToUpper(strings.TrimSpace(country))
+	}
+
+	countryHeader := config.CountryHeader
+	if countryHeader == "" {
+		countryHeader = defaultCountryHeader
+	}
+
+	statusCode := config.StatusCode
+	if statusCode == 0 {
+		statusCode = http.StatusUnavailableForLegalReasons
+	}
+
+	return &geoBlock{
+		countries:     countries,
+		countryHeader: countryHeader,
+		response: &rule{
+			statusCode:  statusCode,
+			body:        config.Body,
+			contentType: config.ContentType,
+			// a legal block is never a decoy or rewrite of the global action
+			action:    actionBlock,
+			blockedBy: config.BlockedBy,
+		},
+	}, nil
+}
+
+// matchGeoBlock matches the country header of the request against the blocked countries.
+// A request without the header is not matched.
+func (blockUrls *traefik_block_regex_urls) matchGeoBlock(request *http.Request) *match {
+	if blockUrls.geoBlock == nil {
+		return nil
+	}
+
+	country := strings.ToUpper(strings.TrimSpace(request.Header.Get(blockUrls.geoBlock.countryHeader)))
+	if country == "" || !slices.Contains(blockUrls.geoBlock.countries, country) {
+		return nil
+	}
+
+	return &match{reason: "geo block", url: fullURL(request), pattern: "country=" + country, rule: blockUrls.geoBlock.response}
+}
+
+// blockedByLink returns the Link header value pointing to the blocking authority, see RFC 7725.
+func blockedByLink(blockedBy string) string {
+	return "<" + blockedBy + `>; rel="blocked-by"`
+}
@@ -0,0 +1,56 @@
+package traefik_block_regex_urls_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+func Test_BlockUrls_GeoBlock_LegalReasons(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login"}
+	cfg.Action = "decoy"
+	cfg.GeoBlock = &BlockUrls.GeoBlock{
+		Countries: []string{"de", "FR"},
+		BlockedBy: "https://authority.example/order-42",
+		Body:      "Unavailable in your country for legal reasons.",
+	}
+
+	handler := newHandler(t, cfg)
+
+	res := serveRequestWithHeaders(t, handler, "http://localhost/article", map[string]string{"X-Country-Code": "DE"})
+
+	assertStatusCode(t, res, http.StatusUnavailableForLegalReasons)
+	assertBody(t, res, "text/plain; charset=utf-8", "Unavailable in your country for legal reasons.")
+
+	if link := res.Header.Get("Link"); link != `<https://authority.example/order-42>; rel="blocked-by"` {
+		t.Errorf("unexpected Link header %q", link)
+	}
+
+	// other countries, requests without a country and other blocks are unaffected
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/article", map[string]string{"X-Country-Code": "US"}), http.StatusOK)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/article"), http.StatusOK)
+
+	res = serveRequest(t, handler, "http://localhost/wp-login")
+	if link := res.Header.Get("Link"); link != "" {
+		t.Errorf("expected no Link header outside the geo block, got %q", link)
+	}
+}
+
+func Test_BlockUrls_GeoBlock_CountryHeader(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.GeoBlock = &BlockUrls.GeoBlock{Countries: []string{"FR"}, CountryHeader: "CF-IPCountry", StatusCode: http.StatusForbidden}
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/", map[string]string{"CF-IPCountry": "fr"}), http.StatusForbidden)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/", map[string]string{"X-Country-Code": "FR"}), http.StatusOK)
+
+	cfg.GeoBlock = &BlockUrls.GeoBlock{}
+
+	if _, err := BlockUrls.New(context.Background(), nil, cfg, "BlockUrls"); err == nil {
+		t.Fatal("expected an error for a geo block without countries")
+	}
+}
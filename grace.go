@@ -0,0 +1,142 @@
+package traefik_block_regex_urls
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// defaultGraceTTL is how long a client ip is remembered after using its first request grace.
+const defaultGraceTTL = 24 * time.Hour
+
+// defaultGraceMaxEntries bounds the number of remembered client ips, which a client can rotate unless the client ip
+// comes from forwardedIPDepth or trustedIPHeader.
+const defaultGraceMaxEntries = 10000
+
+// graceTracker remembers the client ips which already used their one free pass.
+// When full, the ip which used its pass first is forgotten first.
+type graceTracker struct {
+	mu         sync.Mutex
+	ttl        time.Duration
+	maxEntries int
+	seen       map[string]time.Time
+	// order holds the ips in the order of their passes, entries of ips granted again later are stale.
+	order []graceEntry
+
+	lastSweep time.Time
+}
+
+// graceEntry is an ip and the time of its pass.
+type graceEntry struct {
+	ip     string
+	seenAt time.Time
+}
+
+func newGraceTracker(ttl time.Duration, maxEntries int) *graceTracker {
+	return &graceTracker{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		seen:       map[string]time.Time{},
+	}
+}
+
+// grant reports whether the ip gets a free pass, which is the case when it was not seen within the ttl.
+// The ip is recorded either way. Requests without a known client ip never get a free pass.
+func (tracker *graceTracker) grant(ip string, now time.Time) bool {
+	if ip == "" {
+		return false
+	}
+
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	if seenAt, found := tracker.seen[ip]; found && now.Sub(seenAt) < tracker.ttl {
+		return false
+	}
+
+	tracker.removeExpired(now)
+	tracker.record(ip, now)
+
+	return true
+}
+
+// record remembers the pass of the ip, forgetting the oldest passes beyond maxEntries.
+// The caller must hold the lock.
+func (tracker *graceTracker) record(ip string, seenAt time.Time) {
+	tracker.seen[ip] = seenAt
+	tracker.order = append(tracker.order, graceEntry{ip: ip, seenAt: seenAt})
+
+	for len(tracker.seen) > tracker.maxEntries {
+		tracker.forgetOldest()
+	}
+
+	// stale entries pile up when ips are granted again, drop them once they outnumber the live ones
+	if len(tracker.order) > 2*len(tracker.seen)+1 {
+		live := make([]graceEntry, 0, len(tracker.seen))
+		for _, entry := range tracker.order {
+			if tracker.isLive(entry) {
+				live = append(live, entry)
+			}
+		}
+
+		tracker.order = live
+	}
+}
+
+// forgetOldest forgets the ip with the oldest pass. The caller must hold the lock.
+func (tracker *graceTracker) forgetOldest() {
+	for len(tracker.order) > 0 {
+		entry := tracker.order[0]
+		tracker.order = tracker.order[1:]
+
+		if tracker.isLive(entry) {
+			delete(tracker.seen, entry.ip)
+			return
+		}
+	}
+}
+
+// isLive reports whether the entry is the current pass of its ip. The caller must hold the lock.
+func (tracker *graceTracker) isLive(entry graceEntry) bool {
+	seenAt, found := tracker.seen[entry.ip]
+
+	return found && seenAt.Equal(entry.seenAt)
+}
+
+// removeExpired drops the expired entries, at most once per minute, so the seen-set does not grow without bounds.
+// The caller must hold the lock.
+func (tracker *graceTracker) removeExpired(now time.Time) {
+	if now.Sub(tracker.lastSweep) < time.Minute {
+		return
+	}
+
+	tracker.lastSweep = now
+
+	for ip, seenAt := range tracker.seen {
+		if now.Sub(seenAt) >= tracker.ttl {
+			delete(tracker.seen, ip)
+		}
+	}
+}
+
+// restore replaces the remembered ips, e.g. imported with ImportState, dropping the expired ones.
+func (tracker *graceTracker) restore(seen map[string]time.Time, now time.Time) {
+	entries := make([]graceEntry, 0, len(seen))
+	for ip, seenAt := range seen {
+		if now.Sub(seenAt) < tracker.ttl {
+			entries = append(entries, graceEntry{ip: ip, seenAt: seenAt})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].seenAt.Before(entries[j].seenAt) })
+
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	tracker.seen = map[string]time.Time{}
+	tracker.order = nil
+
+	for _, entry := range entries {
+		tracker.record(entry.ip, entry.seenAt)
+	}
+}
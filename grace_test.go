@@ -0,0 +1,101 @@
+package traefik_block_regex_urls_test
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+func Test_BlockUrls_GraceFirstRequest_AllowsOnlyFirstMatch(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login"}
+	cfg.GraceFirstRequest = true
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	firstClient := map[string]string{"X-Forwarded-For": "2.56.20.1"}
+	secondClient := map[string]string{"X-Forwarded-For": "2.56.20.2"}
+
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/wp-login", firstClient), http.StatusOK)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/wp-login", firstClient), http.StatusNotFound)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/wp-login", secondClient), http.StatusOK)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/wp-login", secondClient), http.StatusNotFound)
+}
+
+func Test_BlockUrls_GraceFirstRequest_BlocksUnknownClient(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login"}
+	cfg.GraceFirstRequest = true
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-login"), http.StatusNotFound)
+}
+
+func Test_BlockUrls_GraceFirstRequest_ForgetsOldestBeyondMaxEntries(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login"}
+	cfg.GraceFirstRequest = true
+	cfg.GraceMaxEntries = 2
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	clients := []map[string]string{
+		{"X-Forwarded-For": "2.56.20.1"},
+		{"X-Forwarded-For": "2.56.20.2"},
+		{"X-Forwarded-For": "2.56.20.3"},
+	}
+
+	for _, client := range clients {
+		assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/wp-login", client), http.StatusOK)
+	}
+
+	// the first client was forgotten for the third one
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/wp-login", clients[2]), http.StatusNotFound)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/wp-login", clients[0]), http.StatusOK)
+}
+
+func Test_BlockUrls_GraceFirstRequest_ReturnsError_IfInvalidTTL(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.GraceFirstRequest = true
+	cfg.GraceTTL = "soon"
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	if _, err := BlockUrls.New(context.Background(), next, cfg, "BlockUrls"); err == nil {
+		t.Fatal("expected an error for an invalid graceTTL")
+	}
+}
+
+func Test_BlockUrls_GraceFirstRequest_WarnsWithoutTrustedClientIP(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	cfg := BlockUrls.CreateConfig()
+	cfg.GraceFirstRequest = true
+
+	newHandler(t, cfg)
+
+	if !strings.Contains(buf.String(), "Warning: graceFirstRequest") {
+		t.Errorf("expected a warning without a trusted client ip, got %q", buf.String())
+	}
+
+	buf.Reset()
+	cfg.ForwardedIPDepth = 1
+
+	newHandler(t, cfg)
+
+	if strings.Contains(buf.String(), "Warning: graceFirstRequest") {
+		t.Errorf("expected no warning with forwardedIPDepth, got %q", buf.String())
+	}
+}
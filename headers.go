@@ -0,0 +1,178 @@
+package traefik_block_regex_urls
+
+import (
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// matchHeaders checks the request headers against the header rules.
+// Returns the block reason, or an empty string if no header rule matched.
+func (blockUrls *traefik_block_regex_urls) matchHeaders(request *http.Request) string {
+	if !blockUrls.blockMissingAccept && len(blockUrls.acceptRegexps) == 0 && len(blockUrls.acceptLanguageRegexps) == 0 {
+		return ""
+	}
+
+	accept := strings.TrimSpace(request.Header.Get("Accept"))
+
+	if blockUrls.blockMissingAccept && accept == "" {
+		return "missing accept header"
+	}
+
+	if matchAny(blockUrls.acceptRegexps, accept) {
+		return "accept header match"
+	}
+
+	if matchAny(blockUrls.acceptLanguageRegexps, strings.TrimSpace(request.Header.Get("Accept-Language"))) {
+		return "accept-language header match"
+	}
+
+	return ""
+}
+
+// defaultHeaderScanMaxBytes caps the size of the scanned header blob.
+const defaultHeaderScanMaxBytes = 8192
+
+// headerBlob concatenates the selected header values as "Name: value" lines, sorted by name,
+// truncated to maxBytes. With an include list only those headers are selected, excluded headers never are.
+func headerBlob(header http.Header, include []string, exclude []string, maxBytes int) string {
+	names := make([]string, 0, len(header))
+
+	for name := range header {
+		if len(include) > 0 && !slices.ContainsFunc(include, func(included string) bool { return strings.EqualFold(included, name) }) {
+			continue
+		}
+
+		if slices.ContainsFunc(exclude, func(excluded string) bool { return strings.EqualFold(excluded, name) }) {
+			continue
+		}
+
+		names = append(names, name)
+	}
+
+	slices.Sort(names)
+
+	var blob strings.Builder
+
+	for _, name := range names {
+		for _, value := range header[name] {
+			blob.WriteString(name)
+			blob.WriteString(": ")
+			blob.WriteString(value)
+			blob.WriteString("\n")
+
+			if blob.Len() >= maxBytes {
+				return blob.String()[:maxBytes]
+			}
+		}
+	}
+
+	return blob.String()
+}
+
+// matchHeaderScan matches the header blob against the header scan regexps, or the url regexps if there are none.
+// Returns the matched pattern, or an empty string.
+func (blockUrls *traefik_block_regex_urls) matchHeaderScan(request *http.Request) string {
+	if !blockUrls.scanAllHeaders {
+		return ""
+	}
+
+	regexps := blockUrls.headerScanRegexps
+	if len(regexps) == 0 {
+		blockUrls.compileLazyRegex()
+
+		blockUrls.mu.RLock()
+		regexps = blockUrls.regexps
+		blockUrls.mu.RUnlock()
+	}
+
+	blob := headerBlob(request.Header, blockUrls.scanHeaders, blockUrls.scanHeadersExclude, blockUrls.headerScanMaxBytes)
+
+	for _, regex := range regexps {
+		if regex.MatchString(blob) {
+			return regex.String()
+		}
+	}
+
+	return ""
+}
+
+// matchTrailers matches the names of the trailers a request announces (e.g. "Trailer: X-Checksum") against the regexps.
+// The names are canonicalized, e.g. "X-Checksum". Only the names can be checked, the values follow the body.
+// Returns the first matching regex, or nil. Requests without trailers, e.g. HTTP/1.0, never match.
+func matchTrailers(regexps []*regexp.Regexp, request *http.Request) *regexp.Regexp {
+	if len(request.Trailer) == 0 {
+		return nil
+	}
+
+	for _, regex := range regexps {
+		for name := range request.Trailer {
+			if regex.MatchString(name) {
+				return regex
+			}
+		}
+	}
+
+	return nil
+}
+
+// fingerprintRegexPrefix marks a blocked fingerprint as a regex instead of an exact value.
+const fingerprintRegexPrefix = "regex:"
+
+// compileFingerprints splits the blocked fingerprints into exact values and compiled regexes.
+func compileFingerprints(fingerprints []string) ([]string, []*regexp.Regexp, error) {
+	exactFingerprints := []string{}
+	regexList := []string{}
+
+	for _, fingerprint := range fingerprints {
+		if regex, isRegex := strings.CutPrefix(fingerprint, fingerprintRegexPrefix); isRegex {
+			regexList = append(regexList, regex)
+		} else {
+			exactFingerprints = append(exactFingerprints, fingerprint)
+		}
+	}
+
+	fingerprintRegexps, compileError := compileRegexList(regexList)
+	if compileError != nil {
+		return nil, nil, compileError
+	}
+
+	return exactFingerprints, fingerprintRegexps, nil
+}
+
+// matchFingerprint checks the fingerprint header, set by an upstream proxy, against the blocked fingerprints.
+// Returns the matched pattern, or an empty string if the header is absent or unknown.
+func (blockUrls *traefik_block_regex_urls) matchFingerprint(request *http.Request) string {
+	if blockUrls.fingerprintHeader == "" {
+		return ""
+	}
+
+	fingerprint := strings.TrimSpace(request.Header.Get(blockUrls.fingerprintHeader))
+	if fingerprint == "" {
+		return ""
+	}
+
+	if slices.Contains(blockUrls.blockedFingerprints, fingerprint) {
+		return fingerprint
+	}
+
+	for _, regex := range blockUrls.fingerprintRegexps {
+		if regex.MatchString(fingerprint) {
+			return fingerprintRegexPrefix + regex.String()
+		}
+	}
+
+	return ""
+}
+
+// matchAny reports whether any of the regexps matches the value.
+func matchAny(regexps []*regexp.Regexp, value string) bool {
+	for _, regex := range regexps {
+		if regex.MatchString(value) {
+			return true
+		}
+	}
+
+	return false
+}
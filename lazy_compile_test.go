@@ -0,0 +1,153 @@
+package traefik_block_regex_urls_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+func Test_BlockUrls_LazyCompile(t *testing.T) {
+	for _, combine := range []bool{false, true} {
+		cfg := BlockUrls.CreateConfig()
+		cfg.MatchScope = "path"
+		cfg.Regex = []string{"^/wp-login", "^/xmlrpc"}
+		cfg.LazyCompile = true
+		cfg.CombineRegex = combine
+		cfg.StatusCode = 404
+
+		handler := newHandler(t, cfg)
+
+		var wg sync.WaitGroup
+
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+
+			go func() {
+				defer wg.Done()
+				assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-login"), http.StatusNotFound)
+			}()
+		}
+
+		wg.Wait()
+
+		assertStatusCode(t, serveRequest(t, handler, "http://localhost/xmlrpc"), http.StatusNotFound)
+		assertStatusCode(t, serveRequest(t, handler, "http://localhost/index.html"), http.StatusOK)
+
+		if rules := handler.(rulesLister).Rules(); len(rules) != 2 {
+			t.Errorf("expected the valid regex values to be loaded, got %q", rules)
+		}
+	}
+}
+
+func Test_BlockUrls_LazyCompile_RejectsInvalidUpfront(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"^/wp-login", "(unclosed"}
+	cfg.LazyCompile = true
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	if _, err := BlockUrls.New(context.Background(), next, cfg, "BlockUrls"); err == nil || !strings.Contains(err.Error(), `"(unclosed"`) {
+		t.Fatalf("expected the syntax check to reject the invalid regex, got %v", err)
+	}
+}
+
+func Test_BlockUrls_LazyCompile_ApplyPatch(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.MatchScope = "path"
+	cfg.Regex = []string{"^/wp-login", "^/xmlrpc"}
+	cfg.LazyCompile = true
+
+	handler := newHandler(t, cfg)
+
+	if rules := handler.(rulesLister).Rules(); len(rules) != 2 {
+		t.Errorf("expected the lazy values to be listed, got %q", rules)
+	}
+
+	if err := handler.(patcher).ApplyPatch([]string{`^/\.git/`}, []string{"^/xmlrpc"}); err != nil {
+		t.Fatal(err)
+	}
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-login"), http.StatusForbidden)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/.git/config"), http.StatusForbidden)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/xmlrpc"), http.StatusOK)
+}
+
+func Benchmark_New_ManyPatterns(b *testing.B) {
+	benchmarkNew(b, false)
+}
+
+func Benchmark_New_ManyPatterns_LazyCompile(b *testing.B) {
+	benchmarkNew(b, true)
+}
+
+// the first request matches the first value, with lazyCompile only that one is compiled
+func Benchmark_New_ManyPatterns_FirstRequest(b *testing.B) {
+	benchmarkFirstRequest(b, false)
+}
+
+func Benchmark_New_ManyPatterns_FirstRequest_LazyCompile(b *testing.B) {
+	benchmarkFirstRequest(b, true)
+}
+
+func benchmarkFirstRequest(b *testing.B, lazy bool) {
+	b.Helper()
+
+	cfg := manyRegexConfig(lazy)
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://localhost/probe-0/index.php", nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		handler, err := BlockUrls.New(context.Background(), next, cfg, "BlockUrls")
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+}
+
+func manyRegexConfig(lazy bool) *BlockUrls.Config {
+	cfg := BlockUrls.CreateConfig()
+	cfg.MatchScope = "path"
+	cfg.SilentStartUp = true
+	cfg.LazyCompile = lazy
+
+	for i := 0; i < 5000; i++ {
+		cfg.Regex = append(cfg.Regex, fmt.Sprintf(`^/probe-%d/(.*)\.php$`, i))
+	}
+
+	return cfg
+}
+
+func benchmarkNew(b *testing.B, lazy bool) {
+	b.Helper()
+
+	cfg := BlockUrls.CreateConfig()
+	cfg.LazyCompile = lazy
+
+	for i := 0; i < 5000; i++ {
+		cfg.Regex = append(cfg.Regex, fmt.Sprintf(`^/probe-%d/(.*)\.php$`, i))
+	}
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := BlockUrls.New(context.Background(), next, cfg, "BlockUrls"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package traefik_block_regex_urls
+
+import (
+	"context"
+	"log"
+	"os"
+)
+
+// Log outputs, anything else is a file path.
+const (
+	logOutputStderr = "stderr"
+	logOutputStdout = "stdout"
+)
+
+// newLogger returns the logger for the output. "stderr" (or empty) keeps the standard logger,
+// a file is appended to and closed when ctx is done.
+// Falls back to the standard logger if the file cannot be opened, logging the error with the middleware name.
+func newLogger(ctx context.Context, output string, name string) *log.Logger {
+	switch output {
+	case "", logOutputStderr:
+		return log.Default()
+	case logOutputStdout:
+		return log.New(os.Stdout, "", log.LstdFlags)
+	}
+
+	file, openError := os.OpenFile(output, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o640)
+	if openError != nil {
+		log.Printf("error opening log output %q, logging to stderr: %v middleware=%s", output, openError, name)
+		return log.Default()
+	}
+
+	go func() {
+		<-ctx.Done()
+		_ = file.Close()
+	}()
+
+	return log.New(file, "", log.LstdFlags)
+}
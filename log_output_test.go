@@ -0,0 +1,104 @@
+package traefik_block_regex_urls_test
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+func Test_BlockUrls_LogOutput_File(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "block.log")
+
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login"}
+	cfg.LogOutput = logFile
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	var output bytes.Buffer
+
+	log.SetOutput(&output)
+	defer log.SetOutput(os.Stderr)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-login"), http.StatusNotFound)
+
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(content), "URL is blocked (regex match): (localhost/wp-login)") {
+		t.Errorf("expected the block in the log file, got %q", content)
+	}
+
+	if output.Len() != 0 {
+		t.Errorf("expected nothing on the standard logger, got %q", output.String())
+	}
+}
+
+func Test_BlockUrls_LogOutput_File_RuntimeErrors(t *testing.T) {
+	dir := t.TempDir()
+	logFile := filepath.Join(dir, "block.log")
+
+	rulesDir := filepath.Join(dir, "rules")
+	if err := os.Mkdir(rulesDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(rulesDir, "localhost.txt"), []byte("(invalid\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := BlockUrls.CreateConfig()
+	cfg.RulesDir = rulesDir
+	cfg.LogOutput = logFile
+
+	handler := newHandler(t, cfg)
+
+	var output bytes.Buffer
+
+	log.SetOutput(&output)
+	defer log.SetOutput(os.Stderr)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/index.html"), http.StatusOK)
+
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(content), "error compiling regex \"(invalid\" in host rules file") {
+		t.Errorf("expected the host rules error in the log file, got %q", content)
+	}
+
+	if output.Len() != 0 {
+		t.Errorf("expected nothing on the standard logger, got %q", output.String())
+	}
+}
+
+func Test_BlockUrls_LogOutput_FallsBackToStderr(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login"}
+	cfg.LogOutput = filepath.Join(t.TempDir(), "missing", "block.log")
+	cfg.StatusCode = 404
+
+	var output bytes.Buffer
+
+	log.SetOutput(&output)
+	defer log.SetOutput(os.Stderr)
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-login"), http.StatusNotFound)
+
+	if !strings.Contains(output.String(), "error opening log output") || !strings.Contains(output.String(), "URL is blocked") {
+		t.Errorf("expected the open error and the block on the standard logger, got %q", output.String())
+	}
+}
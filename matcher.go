@@ -0,0 +1,32 @@
+package traefik_block_regex_urls
+
+import "net/http"
+
+// Matcher is a custom block decision, run alongside the built-in rules.
+// Match returns whether the request is blocked and the reason.
+type Matcher interface {
+	Match(request *http.Request) (bool, string)
+}
+
+// MatcherFunc adapts a function to the Matcher interface.
+type MatcherFunc func(request *http.Request) (bool, string)
+
+// Match calls the function.
+func (matcherFunc MatcherFunc) Match(request *http.Request) (bool, string) {
+	return matcherFunc(request)
+}
+
+// matchCustom runs the custom matchers and returns the reason of the first blocking one, or an empty string.
+func (blockUrls *traefik_block_regex_urls) matchCustom(request *http.Request) string {
+	for _, matcher := range blockUrls.matchers {
+		if blocked, reason := matcher.Match(request); blocked {
+			if reason == "" {
+				reason = "custom matcher"
+			}
+
+			return reason
+		}
+	}
+
+	return ""
+}
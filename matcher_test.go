@@ -0,0 +1,68 @@
+package traefik_block_regex_urls_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+type methodMatcher struct {
+	method string
+}
+
+func (matcher methodMatcher) Match(request *http.Request) (bool, string) {
+	return request.Method == matcher.method, "method " + matcher.method
+}
+
+func Test_BlockUrls_CustomMatchers(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login"}
+	cfg.Mode = "tag"
+	cfg.ReasonHeader = "X-Block-Reason"
+
+	var reason string
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		reason = req.Header.Get("X-Block-Reason")
+	})
+
+	longQuery := BlockUrls.MatcherFunc(func(request *http.Request) (bool, string) {
+		return len(request.URL.RawQuery) > 32, "long query"
+	})
+
+	handler, err := BlockUrls.NewWithOptions(context.Background(), next, cfg, "BlockUrls",
+		BlockUrls.WithMatchers(methodMatcher{method: "TRACE"}, longQuery))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method   string
+		url      string
+		expected string
+	}{
+		{"TRACE", "http://localhost/", "method TRACE"},
+		{http.MethodGet, "http://localhost/?q=" + strings.Repeat("a", 40), "long query"},
+		{http.MethodGet, "http://localhost/wp-login", "(.*)/wp-login"},
+		{http.MethodGet, "http://localhost/?q=short", ""},
+	}
+
+	for _, test := range tests {
+		reason = ""
+
+		req, err := http.NewRequestWithContext(context.Background(), test.method, test.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+
+		if reason != test.expected {
+			t.Errorf("%s %s: invalid reason %q <> %q", test.method, test.url, test.expected, reason)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package traefik_block_regex_urls
+
+import (
+	"fmt"
+	"log"
+	"slices"
+)
+
+// Actions when the number of rules exceeds maxRules.
+const (
+	maxRulesActionFail     = "fail"
+	maxRulesActionTruncate = "truncate"
+)
+
+// fixedPatternCount counts the block patterns of the config which truncate cannot drop: exactMatch, the regex
+// values and strings of ruleSets, the conditions of allOf and compositeRegex.
+func fixedPatternCount(config *Config) int {
+	count := len(config.ExactMatch) + len(config.CompositeRegex)
+
+	for _, ruleSet := range config.RuleSets {
+		count += len(ruleSet.Regex) + len(ruleSet.Strings)
+	}
+
+	for _, group := range config.AllOf {
+		count += len(group.QueryKeys)
+
+		if group.Regex != "" {
+			count++
+		}
+
+		if group.UserAgentRegex != "" {
+			count++
+		}
+	}
+
+	return count
+}
+
+// limitRules enforces maxRules on the block patterns of the config, including the strings read from the strings file.
+// Returns the regex values, rules and strings (inline ones first) to compile; with the truncate action the overflow
+// is dropped with a warning, in the order regex values, rules, strings, otherwise an error is returned.
+// A maxRules of zero is unlimited.
+func limitRules(config *Config, fileStrings []string, logger *log.Logger, name string) ([]string, []Rule, []string, error) {
+	regexList, rules := config.Regex, config.Rules
+	matchStrings := append(slices.Clone(config.Strings), fileStrings...)
+	fixed := fixedPatternCount(config)
+
+	total := fixed + len(regexList) + len(rules) + len(matchStrings)
+	if config.MaxRules <= 0 || total <= config.MaxRules {
+		return regexList, rules, matchStrings, nil
+	}
+
+	switch config.MaxRulesAction {
+	case "", maxRulesActionFail:
+		return nil, nil, nil, fmt.Errorf("%d block patterns exceed maxRules %d", total, config.MaxRules)
+	case maxRulesActionTruncate:
+	default:
+		return nil, nil, nil, fmt.Errorf("invalid maxRulesAction %q, expected %q or %q", config.MaxRulesAction, maxRulesActionFail, maxRulesActionTruncate)
+	}
+
+	if fixed > config.MaxRules {
+		return nil, nil, nil, fmt.Errorf("%d exactMatch, ruleSets, allOf and compositeRegex patterns exceed maxRules %d", fixed, config.MaxRules)
+	}
+
+	logger.Printf("Truncated %d block patterns to maxRules %d: middleware=%s", total, config.MaxRules, name)
+
+	room := config.MaxRules - fixed
+
+	regexList = regexList[:min(len(regexList), room)]
+	room -= len(regexList)
+
+	rules = rules[:min(len(rules), room)]
+	room -= len(rules)
+
+	return regexList, rules, matchStrings[:min(len(matchStrings), room)], nil
+}
+
+// fitMaxRules returns how many of the patterns of a source loaded at runtime fit into maxRules next to the others:
+// all of them without a limit, the remaining room with the truncate action. Returns an error when they do not fit
+// otherwise, the caller then keeps what it had.
+func (blockUrls *traefik_block_regex_urls) fitMaxRules(patterns int, others int) (int, error) {
+	if blockUrls.maxRules <= 0 || others+patterns <= blockUrls.maxRules {
+		return patterns, nil
+	}
+
+	if blockUrls.maxRulesAction == maxRulesActionTruncate {
+		return max(blockUrls.maxRules-others, 0), nil
+	}
+
+	return 0, fmt.Errorf("%d patterns exceed maxRules %d next to the %d loaded ones", patterns, blockUrls.maxRules, others)
+}
+
+// fitHostRules returns how many of the patterns of a rulesDir host file fit into maxRules, see fitMaxRules.
+func (blockUrls *traefik_block_regex_urls) fitHostRules(patterns int) (int, error) {
+	blockUrls.mu.RLock()
+	defer blockUrls.mu.RUnlock()
+
+	return blockUrls.fitMaxRules(patterns, blockUrls.loadedPatterns())
+}
+
+// loadedPatterns counts the block patterns loaded besides the rulesDir host files, each of which is limited
+// on its own as only one applies to a request. The caller must hold the lock.
+func (blockUrls *traefik_block_regex_urls) loadedPatterns() int {
+	return blockUrls.staticPatterns + blockUrls.fileStrings + len(blockUrls.denyFeed)
+}
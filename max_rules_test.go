@@ -0,0 +1,158 @@
+package traefik_block_regex_urls_test
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+func maxRulesConfig() *BlockUrls.Config {
+	cfg := BlockUrls.CreateConfig()
+	cfg.MatchScope = "path"
+	cfg.Regex = []string{"^/wp-login", "^/xmlrpc"}
+	cfg.Rules = []BlockUrls.Rule{{Regex: "^/phpmyadmin", StatusCode: 404}}
+	cfg.MaxRules = 2
+
+	return cfg
+}
+
+func Test_BlockUrls_MaxRules_Fail(t *testing.T) {
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	_, err := BlockUrls.New(context.Background(), next, maxRulesConfig(), "BlockUrls")
+	if err == nil || !strings.Contains(err.Error(), "exceed maxRules 2") {
+		t.Errorf("expected a maxRules error, got %v", err)
+	}
+
+	cfg := maxRulesConfig()
+	cfg.MaxRules = 3
+
+	if _, err := BlockUrls.New(context.Background(), next, cfg, "BlockUrls"); err != nil {
+		t.Errorf("expected the rules to fit, got %v", err)
+	}
+
+	cfg.MaxRules = 2
+	cfg.MaxRulesAction = "drop"
+
+	if _, err := BlockUrls.New(context.Background(), next, cfg, "BlockUrls"); err == nil || !strings.Contains(err.Error(), "invalid maxRulesAction") {
+		t.Error("expected an error for an invalid maxRulesAction")
+	}
+}
+
+func Test_BlockUrls_MaxRules_Truncate(t *testing.T) {
+	cfg := maxRulesConfig()
+	cfg.MaxRulesAction = "truncate"
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-login"), http.StatusForbidden)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/xmlrpc"), http.StatusForbidden)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/phpmyadmin"), http.StatusOK)
+
+	cfg.MaxRules = 1
+
+	handler = newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-login"), http.StatusForbidden)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/xmlrpc"), http.StatusOK)
+}
+
+func Test_BlockUrls_MaxRules_ApplyPatch(t *testing.T) {
+	cfg := maxRulesConfig()
+	cfg.Rules = nil
+
+	handler := newHandler(t, cfg)
+
+	if err := handler.(patcher).ApplyPatch([]string{`^/\.git/`}, nil); err == nil {
+		t.Error("expected the patch to exceed maxRules")
+	}
+
+	if err := handler.(patcher).ApplyPatch([]string{`^/\.git/`}, []string{"^/xmlrpc"}); err != nil {
+		t.Fatal(err)
+	}
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/.git/config"), http.StatusForbidden)
+}
+
+func Test_BlockUrls_MaxRules_CountsAllSources(t *testing.T) {
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	cfg := maxRulesConfig()
+	cfg.Rules = nil
+	cfg.MaxRules = 3
+	cfg.StringsFile = writeTempFile(t, "/probe-1\n/probe-2\n")
+
+	if _, err := BlockUrls.New(context.Background(), next, cfg, "BlockUrls"); err == nil || !strings.Contains(err.Error(), "4 block patterns exceed maxRules 3") {
+		t.Errorf("expected the strings file to count toward maxRules, got %v", err)
+	}
+
+	cfg = maxRulesConfig()
+	cfg.Rules = nil
+	cfg.MaxRules = 3
+	cfg.RuleSets = []BlockUrls.RuleSet{{Regex: []string{"^/admin"}, Strings: []string{"/.env"}}}
+
+	if _, err := BlockUrls.New(context.Background(), next, cfg, "BlockUrls"); err == nil {
+		t.Error("expected the rule sets to count toward maxRules")
+	}
+
+	cfg.MaxRulesAction = "truncate"
+	cfg.MaxRules = 1
+
+	if _, err := BlockUrls.New(context.Background(), next, cfg, "BlockUrls"); err == nil {
+		t.Error("expected an error when the rule sets alone exceed maxRules, truncate cannot drop them")
+	}
+}
+
+func Test_BlockUrls_MaxRules_Truncate_StringsFile(t *testing.T) {
+	cfg := maxRulesConfig()
+	cfg.Rules = nil
+	cfg.MaxRules = 3
+	cfg.MaxRulesAction = "truncate"
+	cfg.StringsFile = writeTempFile(t, "/probe-1\n/probe-2\n")
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/xmlrpc"), http.StatusForbidden)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/probe-1"), http.StatusForbidden)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/probe-2"), http.StatusOK)
+}
+
+func Test_BlockUrls_MaxRules_HostRules(t *testing.T) {
+	rulesDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(rulesDir, "localhost.txt"), []byte("^/one\n^/two\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	var output bytes.Buffer
+
+	log.SetOutput(&output)
+	defer log.SetOutput(os.Stderr)
+
+	cfg := maxRulesConfig()
+	cfg.Rules = nil
+	cfg.MaxRules = 3
+	cfg.RulesDir = rulesDir
+
+	handler := newHandler(t, cfg)
+
+	// the host file does not fit, so it is not loaded
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/one"), http.StatusOK)
+
+	if !strings.Contains(output.String(), "error loading host rules file") {
+		t.Errorf("expected the rejected host file to be logged, got %q", output.String())
+	}
+
+	cfg.MaxRulesAction = "truncate"
+
+	handler = newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/one"), http.StatusForbidden)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/two"), http.StatusOK)
+}
@@ -0,0 +1,126 @@
+package traefik_block_regex_urls
+
+import (
+	"hash/fnv"
+	"sync"
+	"time"
+)
+
+// defaultMinIntervalMaxEntries bounds the number of remembered requests, which a client can grow by rotating
+// X-Forwarded-For or the query.
+const defaultMinIntervalMaxEntries = 10000
+
+// intervalTracker remembers when each request was last seen, by client ip, method, host and request uri,
+// to spot identical requests repeated faster than a human can. Requests are kept as hashes; when full,
+// the request seen longest ago is forgotten first.
+type intervalTracker struct {
+	mu          sync.Mutex
+	minInterval time.Duration
+	maxEntries  int
+	lastSeen    map[uint64]time.Time
+	// order holds the requests in the order they were seen, entries of requests seen again later are stale.
+	order []intervalEntry
+}
+
+// intervalEntry is a request hash and the time it was seen.
+type intervalEntry struct {
+	key    uint64
+	seenAt time.Time
+}
+
+func newIntervalTracker(minInterval time.Duration, maxEntries int) *intervalTracker {
+	return &intervalTracker{
+		minInterval: minInterval,
+		maxEntries:  maxEntries,
+		lastSeen:    map[uint64]time.Time{},
+	}
+}
+
+// tooFast reports whether the previous request with the key arrived less than the minimum interval ago.
+// The request time is recorded either way. An empty key, of a request without a known client ip, is never too fast.
+func (tracker *intervalTracker) tooFast(key string, now time.Time) bool {
+	if key == "" {
+		return false
+	}
+
+	hash := fnv.New64a()
+	_, _ = hash.Write([]byte(key))
+	hashedKey := hash.Sum64()
+
+	tracker.mu.Lock()
+	defer tracker.mu.Unlock()
+
+	tracker.removeExpired(now)
+
+	lastSeen, found := tracker.lastSeen[hashedKey]
+	tracker.record(hashedKey, now)
+
+	return found && now.Sub(lastSeen) < tracker.minInterval
+}
+
+// record remembers the request, forgetting the ones seen longest ago beyond maxEntries.
+// The caller must hold the lock.
+func (tracker *intervalTracker) record(key uint64, seenAt time.Time) {
+	tracker.lastSeen[key] = seenAt
+	tracker.order = append(tracker.order, intervalEntry{key: key, seenAt: seenAt})
+
+	for len(tracker.lastSeen) > tracker.maxEntries {
+		tracker.forgetOldest()
+	}
+
+	// stale entries pile up when requests are repeated, drop them once they outnumber the live ones
+	if len(tracker.order) > 2*len(tracker.lastSeen)+1 {
+		live := make([]intervalEntry, 0, len(tracker.lastSeen))
+		for _, entry := range tracker.order {
+			if tracker.isLive(entry) {
+				live = append(live, entry)
+			}
+		}
+
+		tracker.order = live
+	}
+}
+
+// forgetOldest forgets the request seen longest ago. The caller must hold the lock.
+func (tracker *intervalTracker) forgetOldest() {
+	for len(tracker.order) > 0 {
+		entry := tracker.order[0]
+		tracker.order = tracker.order[1:]
+
+		if tracker.isLive(entry) {
+			delete(tracker.lastSeen, entry.key)
+			return
+		}
+	}
+}
+
+// isLive reports whether the entry is the last sighting of its request. The caller must hold the lock.
+func (tracker *intervalTracker) isLive(entry intervalEntry) bool {
+	seenAt, found := tracker.lastSeen[entry.key]
+
+	return found && seenAt.Equal(entry.seenAt)
+}
+
+// removeExpired drops the requests not seen within the minimum interval, which are the oldest ones.
+// The caller must hold the lock.
+func (tracker *intervalTracker) removeExpired(now time.Time) {
+	for len(tracker.order) > 0 && now.Sub(tracker.order[0].seenAt) >= tracker.minInterval {
+		entry := tracker.order[0]
+		tracker.order = tracker.order[1:]
+
+		if tracker.isLive(entry) {
+			delete(tracker.lastSeen, entry.key)
+		}
+	}
+}
+
+// repeatKey identifies identical requests of a client, so a page load fetching its assets, or several users
+// behind one NAT, are no repeats. Empty for requests without a known client ip.
+func repeatKey(client *requestClient) string {
+	ip := client.clientIP()
+	if ip == "" {
+		return ""
+	}
+
+	return ip + " " + client.request.Method + " " + client.request.Host + client.request.URL.RequestURI()
+}
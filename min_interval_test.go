@@ -0,0 +1,87 @@
+package traefik_block_regex_urls_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+func Test_BlockUrls_MinInterval_BlocksRapidRepeats(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.MinInterval = "50ms"
+
+	clock := newFakeClock()
+
+	handler, err := BlockUrls.NewWithOptions(context.Background(), nil, cfg, "BlockUrls", BlockUrls.WithClock(clock.Now))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	firstClient := map[string]string{"X-Forwarded-For": "203.0.113.1"}
+	secondClient := map[string]string{"X-Forwarded-For": "203.0.113.2"}
+
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/", firstClient), http.StatusOK)
+
+	clock.Advance(5 * time.Millisecond)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/", firstClient), http.StatusForbidden)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/", secondClient), http.StatusOK)
+
+	clock.Advance(100 * time.Millisecond)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/", firstClient), http.StatusOK)
+}
+
+func Test_BlockUrls_MinInterval_OnlyIdenticalRequests(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.MinInterval = "50ms"
+
+	clock := newFakeClock()
+
+	handler, err := BlockUrls.NewWithOptions(context.Background(), nil, cfg, "BlockUrls", BlockUrls.WithClock(clock.Now))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := map[string]string{"X-Forwarded-For": "203.0.113.1"}
+
+	// a page load fetching its assets at once
+	for _, url := range []string{"http://localhost/", "http://localhost/style.css", "http://localhost/app.js", "http://localhost/?page=2", "http://example.com/"} {
+		assertStatusCode(t, serveRequestWithHeaders(t, handler, url, client), http.StatusOK)
+	}
+
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/app.js", client), http.StatusForbidden)
+}
+
+func Test_BlockUrls_MinInterval_ForgetsOldestBeyondMaxEntries(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.MinInterval = "50ms"
+	cfg.MinIntervalMaxEntries = 2
+
+	clock := newFakeClock()
+
+	handler, err := BlockUrls.NewWithOptions(context.Background(), nil, cfg, "BlockUrls", BlockUrls.WithClock(clock.Now))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := map[string]string{"X-Forwarded-For": "203.0.113.1"}
+
+	for _, url := range []string{"http://localhost/?a", "http://localhost/?b", "http://localhost/?c"} {
+		assertStatusCode(t, serveRequestWithHeaders(t, handler, url, client), http.StatusOK)
+	}
+
+	// ?a was forgotten to make room for ?c, ?c is still remembered
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/?a", client), http.StatusOK)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/?c", client), http.StatusForbidden)
+}
+
+func Test_BlockUrls_MinInterval_ReturnsError_IfInvalid(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.MinInterval = "fast"
+
+	if _, err := BlockUrls.New(context.Background(), nil, cfg, "BlockUrls"); err == nil {
+		t.Fatal("expected an error for an invalid minInterval")
+	}
+}
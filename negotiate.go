@@ -0,0 +1,80 @@
+package traefik_block_regex_urls
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Media types of the negotiated block bodies.
+const (
+	mediaTypeJSON = "application/json"
+	mediaTypeHTML = "text/html"
+)
+
+// negotiate returns the offer the Accept header prefers, by quality, then by order of the offers.
+// Only explicit ranges (e.g. text/html or text/*) select an offer, a bare */* does not,
+// so clients accepting anything get the default body. Returns "" if no offer is accepted.
+func negotiate(accept string, offers []string) string {
+	if accept == "" || len(offers) == 0 {
+		return ""
+	}
+
+	bestOffer, bestQuality := "", 0.0
+
+	for _, offer := range offers {
+		if quality := acceptQuality(accept, offer); quality > bestQuality {
+			bestOffer, bestQuality = offer, quality
+		}
+	}
+
+	return bestOffer
+}
+
+// acceptQuality returns the quality of the most specific range of the Accept header matching the media type,
+// or 0 if only */* or nothing matches.
+func acceptQuality(accept string, mediaType string) float64 {
+	mainType, _, _ := strings.Cut(mediaType, "/")
+	quality, specificity := 0.0, 0
+
+	for _, mediaRange := range strings.Split(accept, ",") {
+		rangeType, params, _ := strings.Cut(mediaRange, ";")
+		rangeType = strings.ToLower(strings.TrimSpace(rangeType))
+
+		rangeSpecificity := 0
+		switch rangeType {
+		case mediaType:
+			rangeSpecificity = 2
+		case mainType + "/*":
+			rangeSpecificity = 1
+		default:
+			continue
+		}
+
+		if rangeSpecificity < specificity {
+			continue
+		}
+
+		specificity, quality = rangeSpecificity, parseQuality(params)
+	}
+
+	return quality
+}
+
+// parseQuality returns the q parameter of a media range, 1 if absent or invalid.
+func parseQuality(params string) float64 {
+	for _, param := range strings.Split(params, ";") {
+		name, value, found := strings.Cut(strings.TrimSpace(param), "=")
+		if !found || !strings.EqualFold(name, "q") {
+			continue
+		}
+
+		quality, parseError := strconv.ParseFloat(value, 64)
+		if parseError != nil || quality < 0 || quality > 1 {
+			return 1
+		}
+
+		return quality
+	}
+
+	return 1
+}
@@ -0,0 +1,6 @@
+//go:build !race
+
+package traefik_block_regex_urls_test
+
+// raceEnabled reports whether the tests run with the race detector, which instruments allocations.
+const raceEnabled = false
@@ -0,0 +1,56 @@
+package traefik_block_regex_urls
+
+import (
+	"net/http"
+	"time"
+)
+
+// Option customizes the plugin beyond its Config, for embedders and tests.
+type Option func(blockUrls *traefik_block_regex_urls)
+
+// WithResolver replaces the DNS resolver used to verify bots.
+func WithResolver(resolver Resolver) Option {
+	return func(blockUrls *traefik_block_regex_urls) {
+		if blockUrls.botVerifier != nil {
+			blockUrls.botVerifier.resolver = resolver
+		}
+	}
+}
+
+// WithClock replaces the time source, e.g. with a fake clock in tests.
+func WithClock(now func() time.Time) Option {
+	return func(blockUrls *traefik_block_regex_urls) {
+		blockUrls.now = now
+	}
+}
+
+// WithRand replaces the random source of the block delay jitter, e.g. with a fixed one in tests.
+// It must return a number in [0, n) like rand.Int63n, and be safe for concurrent use.
+func WithRand(randInt63n func(n int64) int64) Option {
+	return func(blockUrls *traefik_block_regex_urls) {
+		blockUrls.randInt63n = randInt63n
+	}
+}
+
+// WithMatchers adds custom matchers, run alongside the built-in rules.
+func WithMatchers(matchers ...Matcher) Option {
+	return func(blockUrls *traefik_block_regex_urls) {
+		blockUrls.matchers = append(blockUrls.matchers, matchers...)
+	}
+}
+
+// WithEvents sends a BlockEvent for every blocked request to the channel.
+// Sending never blocks the request: when the channel is full, the event is dropped.
+func WithEvents(events chan<- BlockEvent) Option {
+	return func(blockUrls *traefik_block_regex_urls) {
+		blockUrls.events = events
+	}
+}
+
+// WithBlockHandler hands blocked requests to the handler instead of writing the block response,
+// e.g. to render a custom page. The handler can read the block reason with DecisionFromContext.
+func WithBlockHandler(handler http.Handler) Option {
+	return func(blockUrls *traefik_block_regex_urls) {
+		blockUrls.blockHandler = handler
+	}
+}
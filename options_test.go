@@ -0,0 +1,90 @@
+package traefik_block_regex_urls_test
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+// fakeClock is a manually advanced time source.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2025, 6, 2, 12, 0, 0, 0, time.UTC)}
+}
+
+func (clock *fakeClock) Now() time.Time {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+
+	return clock.now
+}
+
+func (clock *fakeClock) Advance(duration time.Duration) {
+	clock.mu.Lock()
+	defer clock.mu.Unlock()
+
+	clock.now = clock.now.Add(duration)
+}
+
+func Test_BlockUrls_WithClock_ExpiresGraceRecord(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login"}
+	cfg.GraceFirstRequest = true
+	cfg.GraceTTL = "1h"
+	cfg.StatusCode = 404
+
+	clock := newFakeClock()
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	handler, err := BlockUrls.NewWithOptions(context.Background(), next, cfg, "BlockUrls", BlockUrls.WithClock(clock.Now))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client := map[string]string{"X-Forwarded-For": "2.56.20.1"}
+
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/wp-login", client), http.StatusOK)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/wp-login", client), http.StatusNotFound)
+
+	clock.Advance(59 * time.Minute)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/wp-login", client), http.StatusNotFound)
+
+	clock.Advance(time.Minute)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/wp-login", client), http.StatusOK)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/wp-login", client), http.StatusNotFound)
+}
+
+func Test_BlockUrls_WithBlockHandler(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login"}
+	cfg.StatusCode = 404
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	blockHandler := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		decision, _ := BlockUrls.DecisionFromContext(req.Context())
+
+		rw.WriteHeader(http.StatusTeapot)
+		_, _ = rw.Write([]byte("custom block page: " + decision.Pattern))
+	})
+
+	handler, err := BlockUrls.NewWithOptions(context.Background(), next, cfg, "BlockUrls", BlockUrls.WithBlockHandler(blockHandler))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res := serveRequest(t, handler, "http://localhost/wp-login")
+
+	assertStatusCode(t, res, http.StatusTeapot)
+	assertBody(t, res, "", "custom block page: (.*)/wp-login")
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/index.html"), http.StatusOK)
+}
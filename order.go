@@ -0,0 +1,36 @@
+package traefik_block_regex_urls
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+// Orders of the allow and block rules, like the Order directive of Apache.
+const (
+	// orderDenyAllow evaluates the block rules first and lets the allow rules override them, allowing by default.
+	orderDenyAllow = "deny,allow"
+	// orderAllowDeny evaluates the allow rules first and lets the block rules override them, denying by default.
+	orderAllowDeny = "allow,deny"
+)
+
+// parseOrder validates an order, empty falls back to deny,allow.
+func parseOrder(order string) (string, error) {
+	switch order {
+	case "", orderDenyAllow:
+		return orderDenyAllow, nil
+	case orderAllowDeny:
+		return orderAllowDeny, nil
+	default:
+		return "", fmt.Errorf("invalid order %q, expected %q or %q", order, orderDenyAllow, orderAllowDeny)
+	}
+}
+
+// matchAllowRules reports whether the request matches allowQueryStrings or allowRegex.
+func (blockUrls *traefik_block_regex_urls) matchAllowRules(request *http.Request) bool {
+	if len(blockUrls.allowQueryStrings) > 0 && request.URL.RawQuery != "" && slices.Contains(blockUrls.allowQueryStrings, request.URL.RawQuery) {
+		return true
+	}
+
+	return len(blockUrls.allowRegexps) > 0 && matchAny(blockUrls.allowRegexps, blockUrls.matchTarget(request))
+}
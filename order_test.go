@@ -0,0 +1,81 @@
+package traefik_block_regex_urls_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+func Test_BlockUrls_Order(t *testing.T) {
+	tests := []struct {
+		order    string
+		url      string
+		expected int
+	}{
+		// matches the allow rule and the block rule
+		{order: "", url: "http://localhost/admin/health", expected: http.StatusOK},
+		{order: "deny,allow", url: "http://localhost/admin/health", expected: http.StatusOK},
+		{order: "allow,deny", url: "http://localhost/admin/health", expected: http.StatusForbidden},
+		// matches the allow rule only
+		{order: "deny,allow", url: "http://localhost/app/health", expected: http.StatusOK},
+		{order: "allow,deny", url: "http://localhost/app/health", expected: http.StatusOK},
+		// matches the block rule only
+		{order: "deny,allow", url: "http://localhost/admin", expected: http.StatusForbidden},
+		{order: "allow,deny", url: "http://localhost/admin", expected: http.StatusForbidden},
+		// matches neither
+		{order: "deny,allow", url: "http://localhost/home", expected: http.StatusOK},
+		{order: "allow,deny", url: "http://localhost/home", expected: http.StatusForbidden},
+	}
+
+	for _, test := range tests {
+		cfg := BlockUrls.CreateConfig()
+		cfg.Regex = []string{"(.*)/admin"}
+		cfg.AllowRegex = []string{"(.*)/health$"}
+		cfg.Order = test.order
+
+		res := serveRequest(t, newHandler(t, cfg), test.url)
+
+		if res.StatusCode != test.expected {
+			t.Errorf("order %q, %s: expected status %d, got %d", test.order, test.url, test.expected, res.StatusCode)
+		}
+	}
+}
+
+func Test_BlockUrls_Order_AllowDeny_DefaultDeny(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/admin"}
+	cfg.AllowRegex = []string{"(.*)/health$"}
+	cfg.Order = "allow,deny"
+	cfg.DefaultDeny = true
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/app/health"), http.StatusOK)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/admin/health"), http.StatusForbidden)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/home"), http.StatusForbidden)
+}
+
+func Test_BlockUrls_Order_BypassIPs(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/admin"}
+	cfg.BypassIPs = []string{"203.0.113.0/24"}
+	cfg.Order = "allow,deny"
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/home", map[string]string{"X-Forwarded-For": "203.0.113.7"}), http.StatusOK)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/admin", map[string]string{"X-Forwarded-For": "203.0.113.7"}), http.StatusForbidden)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/home", map[string]string{"X-Forwarded-For": "198.51.100.7"}), http.StatusForbidden)
+}
+
+func Test_BlockUrls_Order_Invalid(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Order = "allow"
+
+	_, err := BlockUrls.New(context.Background(), nil, cfg, "BlockUrls")
+	if err == nil {
+		t.Fatal("expected an error for an invalid order")
+	}
+}
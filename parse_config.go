@@ -0,0 +1,44 @@
+package traefik_block_regex_urls
+
+import (
+	"fmt"
+	"strings"
+)
+
+// stringPrefix marks a substring line in a ruleset.
+const stringPrefix = "str:"
+
+// ParseConfig builds a Config from a ruleset with one pattern per line, e.g. for embedders and tests:
+//
+//	# scanners
+//	^localhost/wp(.*)
+//	str:/phpmyadmin
+//
+// Lines are regex values, lines prefixed with "str:" are substrings. Blank lines and lines starting with '#'
+// are ignored. The other settings keep the CreateConfig defaults. Returns an error for an invalid regex.
+func ParseConfig(text string) (*Config, error) {
+	lines, readError := parsePatternLines(strings.NewReader(text))
+	if readError != nil {
+		return nil, readError
+	}
+
+	config := CreateConfig()
+
+	for _, line := range lines {
+		if matchString, isString := strings.CutPrefix(line, stringPrefix); isString {
+			if matchString = strings.TrimSpace(matchString); matchString != "" {
+				config.Strings = append(config.Strings, matchString)
+			}
+
+			continue
+		}
+
+		config.Regex = append(config.Regex, line)
+	}
+
+	if _, compileError := compileRegexList(config.Regex); compileError != nil {
+		return nil, fmt.Errorf("invalid ruleset: %w", compileError)
+	}
+
+	return config, nil
+}
@@ -0,0 +1,51 @@
+package traefik_block_regex_urls_test
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+func Test_ParseConfig(t *testing.T) {
+	cfg, err := BlockUrls.ParseConfig(`
+# scanners
+^localhost/wp(.*)
+  (.*)/xmlrpc\.php$
+
+str:/phpmyadmin
+str: .env
+str:
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := []string{`^localhost/wp(.*)`, `(.*)/xmlrpc\.php$`}; !slices.Equal(cfg.Regex, expected) {
+		t.Errorf("invalid regex: %q <> %q", expected, cfg.Regex)
+	}
+
+	if expected := []string{"/phpmyadmin", ".env"}; !slices.Equal(cfg.Strings, expected) {
+		t.Errorf("invalid strings: %q <> %q", expected, cfg.Strings)
+	}
+
+	if cfg.StatusCode != BlockUrls.CreateConfig().StatusCode {
+		t.Errorf("expected the default status code, got %d", cfg.StatusCode)
+	}
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-login"), http.StatusForbidden)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/phpmyadmin/index.php"), http.StatusForbidden)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/app/.env"), http.StatusForbidden)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/index.html"), http.StatusOK)
+}
+
+func Test_ParseConfig_InvalidRegex(t *testing.T) {
+	_, err := BlockUrls.ParseConfig("^/ok\n(unclosed\n")
+	if err == nil || !strings.Contains(err.Error(), `"(unclosed"`) {
+		t.Errorf("expected an error naming the invalid regex, got %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package traefik_block_regex_urls
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+)
+
+// ApplyPatch adds and removes regex values at runtime, without recompiling the unchanged ones.
+// Removed values are matched by their source text, unknown ones are ignored; values already loaded are not added twice.
+// On error, e.g. an invalid added value or exceeding maxRules, nothing is changed.
+func (blockUrls *traefik_block_regex_urls) ApplyPatch(add []string, remove []string) error {
+	added, compileError := compileRegexList(add)
+	if compileError != nil {
+		return compileError
+	}
+
+	blockUrls.compileLazyRegex()
+
+	blockUrls.mu.Lock()
+	defer blockUrls.mu.Unlock()
+
+	regexps := make([]*regexp.Regexp, 0, len(blockUrls.regexps)+len(added))
+
+	for _, regex := range blockUrls.regexps {
+		if !slices.Contains(remove, regex.String()) {
+			regexps = append(regexps, regex)
+		}
+	}
+
+	for _, regex := range added {
+		if !slices.ContainsFunc(regexps, func(loaded *regexp.Regexp) bool { return loaded.String() == regex.String() }) {
+			regexps = append(regexps, regex)
+		}
+	}
+
+	// the patched regex values replace the loaded ones in the count, a patch is never truncated
+	if loaded := blockUrls.loadedPatterns() - len(blockUrls.regexps) + len(regexps); blockUrls.maxRules > 0 && loaded > blockUrls.maxRules {
+		return fmt.Errorf("%d block patterns exceed maxRules %d", loaded, blockUrls.maxRules)
+	}
+
+	if blockUrls.combineRegex {
+		combined, combineError := blockUrls.buildCombined(regexps, blockUrls.matchStrings)
+		if combineError != nil {
+			return fmt.Errorf("error combining regex: %w", combineError)
+		}
+
+		blockUrls.combined = combined
+	}
+
+	blockUrls.staticPatterns += len(regexps) - len(blockUrls.regexps)
+	blockUrls.regexps = regexps
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package traefik_block_regex_urls_test
+
+import (
+	"net/http"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+type patcher interface {
+	ApplyPatch(add []string, remove []string) error
+}
+
+func Test_BlockUrls_ApplyPatch_AddsAndRemovesRegex(t *testing.T) {
+	for _, combine := range []bool{false, true} {
+		cfg := BlockUrls.CreateConfig()
+		cfg.MatchScope = "path"
+		cfg.Regex = []string{"^/wp-login", "^/xmlrpc"}
+		cfg.CombineRegex = combine
+
+		handler := newHandler(t, cfg)
+
+		assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-login"), http.StatusForbidden)
+		assertStatusCode(t, serveRequest(t, handler, "http://localhost/.git/config"), http.StatusOK)
+
+		if err := handler.(patcher).ApplyPatch([]string{`^/\.git/`}, []string{"^/wp-login"}); err != nil {
+			t.Fatal(err)
+		}
+
+		assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-login"), http.StatusOK)
+		assertStatusCode(t, serveRequest(t, handler, "http://localhost/.git/config"), http.StatusForbidden)
+		assertStatusCode(t, serveRequest(t, handler, "http://localhost/xmlrpc"), http.StatusForbidden)
+	}
+}
+
+func Test_BlockUrls_ApplyPatch_KeepsRegex_IfInvalid(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.MatchScope = "path"
+	cfg.Regex = []string{"^/wp-login"}
+
+	handler := newHandler(t, cfg)
+
+	if err := handler.(patcher).ApplyPatch([]string{"(unclosed"}, []string{"^/wp-login"}); err == nil {
+		t.Fatal("expected an error for an invalid regex")
+	}
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-login"), http.StatusForbidden)
+}
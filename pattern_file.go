@@ -0,0 +1,83 @@
+package traefik_block_regex_urls
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// readPatternFile reads one pattern per line from the given file.
+// Blank lines and lines starting with '#' are ignored, surrounding whitespace is trimmed.
+func readPatternFile(path string) ([]string, error) {
+	file, openError := os.Open(path)
+	if openError != nil {
+		return nil, openError
+	}
+	defer file.Close()
+
+	return parsePatternLines(file)
+}
+
+// parsePatternLines collects the non-blank, non-comment lines of a reader.
+func parsePatternLines(reader io.Reader) ([]string, error) {
+	patterns := []string{}
+	scanner := bufio.NewScanner(reader)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		patterns = append(patterns, line)
+	}
+
+	return patterns, scanner.Err()
+}
+
+// watchPatternFile starts polling the file modification time every interval and calls onChange with the new content.
+// Read errors are logged to the logger with the middleware name and the previous content is kept.
+// Polling stops when ctx is done.
+func watchPatternFile(ctx context.Context, path string, interval time.Duration, name string, logger *log.Logger, onChange func([]string)) {
+	var lastModified time.Time
+	if info, statError := os.Stat(path); statError == nil {
+		lastModified = info.ModTime()
+	}
+
+	go pollPatternFile(ctx, path, interval, name, logger, lastModified, onChange)
+}
+
+func pollPatternFile(ctx context.Context, path string, interval time.Duration, name string, logger *log.Logger, lastModified time.Time, onChange func([]string)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, statError := os.Stat(path)
+			if statError != nil {
+				logger.Printf("error checking pattern file %q: %v middleware=%s", path, statError, name)
+				continue
+			}
+
+			if !info.ModTime().After(lastModified) {
+				continue
+			}
+
+			patterns, readError := readPatternFile(path)
+			if readError != nil {
+				logger.Printf("error reloading pattern file %q: %v middleware=%s", path, readError, name)
+				continue
+			}
+
+			lastModified = info.ModTime()
+			onChange(patterns)
+		}
+	}
+}
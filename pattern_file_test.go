@@ -0,0 +1,129 @@
+package traefik_block_regex_urls_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+func Test_BlockUrls_StringsFile_IgnoresCommentsAndBlankLines(t *testing.T) {
+	stringsFile := writeTempFile(t, "# known bad substrings\n\n  /phpmyadmin  \n# /index.html\n.env\n")
+
+	cfg := BlockUrls.CreateConfig()
+	cfg.StringsFile = stringsFile
+	cfg.StatusCode = 404
+
+	ctx := context.Background()
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	handler, err := BlockUrls.New(ctx, next, cfg, "BlockUrls")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]int{
+		"http://localhost/phpmyadmin/index.php": http.StatusNotFound,
+		"http://localhost/app/.env":             http.StatusNotFound,
+		"http://localhost/index.html":           http.StatusOK,
+		"http://localhost/":                     http.StatusOK,
+	}
+
+	for url, expected := range tests {
+		recorder := httptest.NewRecorder()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		handler.ServeHTTP(recorder, req)
+
+		assertStatusCode(t, recorder.Result(), expected)
+	}
+}
+
+func Test_BlockUrls_StringsFile_ReturnsError_IfMissing(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.StringsFile = filepath.Join(t.TempDir(), "missing.txt")
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	if _, err := BlockUrls.New(context.Background(), next, cfg, "BlockUrls"); err == nil {
+		t.Fatal("expected an error for a missing strings file")
+	}
+}
+
+func Test_BlockUrls_StringsFile_ReloadsOnChange(t *testing.T) {
+	stringsFile := writeTempFile(t, "/old-probe\n")
+
+	cfg := BlockUrls.CreateConfig()
+	cfg.StringsFile = stringsFile
+	cfg.StringsFileReloadInterval = "10ms"
+	cfg.StatusCode = 404
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	handler, err := BlockUrls.New(ctx, next, cfg, "BlockUrls")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	newModTime := time.Now().Add(time.Second)
+	if err := os.WriteFile(stringsFile, []byte("/new-probe\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(stringsFile, newModTime, newModTime); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		recorder := httptest.NewRecorder()
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/new-probe", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		handler.ServeHTTP(recorder, req)
+
+		if recorder.Result().StatusCode == http.StatusNotFound {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("strings file was not reloaded in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	recorder := httptest.NewRecorder()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/old-probe", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	handler.ServeHTTP(recorder, req)
+
+	assertStatusCode(t, recorder.Result(), http.StatusOK)
+}
+
+func writeTempFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "patterns.txt")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
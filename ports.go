@@ -0,0 +1,49 @@
+package traefik_block_regex_urls
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// validatePorts checks that every port is a valid tcp port.
+func validatePorts(ports []int) error {
+	for _, port := range ports {
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("invalid port %d in ports", port)
+		}
+	}
+
+	return nil
+}
+
+// inPortScope reports whether the rules apply to the port of the request, always true without configured ports.
+func (blockUrls *traefik_block_regex_urls) inPortScope(request *http.Request) bool {
+	return len(blockUrls.ports) == 0 || slices.Contains(blockUrls.ports, requestPort(request))
+}
+
+// requestPort returns the port the request was received on: the X-Forwarded-Port header set by Traefik or
+// another proxy, else the port of the Host header, else the default port of the scheme.
+func requestPort(request *http.Request) int {
+	if forwardedPort := request.Header.Get("X-Forwarded-Port"); forwardedPort != "" {
+		port, _, _ := strings.Cut(forwardedPort, ",")
+		if parsedPort, parseError := strconv.Atoi(strings.TrimSpace(port)); parseError == nil {
+			return parsedPort
+		}
+	}
+
+	if _, port, splitError := net.SplitHostPort(request.Host); splitError == nil {
+		if parsedPort, parseError := strconv.Atoi(port); parseError == nil {
+			return parsedPort
+		}
+	}
+
+	if requestScheme(request) == "https" {
+		return 443
+	}
+
+	return 80
+}
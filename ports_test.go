@@ -0,0 +1,36 @@
+package traefik_block_regex_urls_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+func Test_BlockUrls_Ports(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login"}
+	cfg.Ports = []int{80, 8443}
+
+	handler := newHandler(t, cfg)
+
+	// in scope, from the default port, the host and the forwarded port
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-login"), http.StatusForbidden)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost:8443/wp-login"), http.StatusForbidden)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/wp-login", map[string]string{"X-Forwarded-Port": "8443"}), http.StatusForbidden)
+
+	// out of scope
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost:9000/wp-login"), http.StatusOK)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/wp-login", map[string]string{"X-Forwarded-Port": "443"}), http.StatusOK)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/wp-login", map[string]string{"X-Forwarded-Proto": "https"}), http.StatusOK)
+}
+
+func Test_BlockUrls_Ports_ReturnsError_IfInvalid(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Ports = []int{70000}
+
+	if _, err := BlockUrls.New(context.Background(), nil, cfg, "BlockUrls"); err == nil {
+		t.Fatal("expected an error for an invalid port")
+	}
+}
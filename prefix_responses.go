@@ -0,0 +1,57 @@
+package traefik_block_regex_urls
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// PrefixResponse is the block response of the paths starting with a prefix, e.g. a JSON body for "/api/"
+// and the HTML block page for everything else. Unset fields fall back to the global ones.
+type PrefixResponse struct {
+	Prefix      string `yaml:"prefix"`
+	StatusCode  int    `yaml:"statusCode,omitempty"`
+	ContentType string `yaml:"contentType,omitempty"`
+	Body        string `yaml:"body,omitempty"`
+}
+
+// prefixResponse is a compiled PrefixResponse.
+type prefixResponse struct {
+	prefix   string
+	response *rule
+}
+
+// compilePrefixResponses returns the prefix responses, longest prefix first so the most specific one wins.
+func compilePrefixResponses(configResponses []PrefixResponse) ([]prefixResponse, error) {
+	prefixResponses := make([]prefixResponse, len(configResponses))
+
+	for index, configResponse := range configResponses {
+		if !strings.HasPrefix(configResponse.Prefix, "/") {
+			return nil, fmt.Errorf("invalid prefix %q in prefixResponses, expected an absolute path", configResponse.Prefix)
+		}
+
+		prefixResponses[index] = prefixResponse{
+			prefix: configResponse.Prefix,
+			response: &rule{
+				statusCode:  configResponse.StatusCode,
+				body:        configResponse.Body,
+				contentType: configResponse.ContentType,
+			},
+		}
+	}
+
+	sort.SliceStable(prefixResponses, func(i, j int) bool { return len(prefixResponses[i].prefix) > len(prefixResponses[j].prefix) })
+
+	return prefixResponses, nil
+}
+
+// prefixResponseFor returns the response of the longest prefix of the path, or nil if none applies.
+func (blockUrls *traefik_block_regex_urls) prefixResponseFor(path string) *rule {
+	for _, prefixResponse := range blockUrls.prefixResponses {
+		if strings.HasPrefix(path, prefixResponse.prefix) {
+			return prefixResponse.response
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,175 @@
+package traefik_block_regex_urls
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"unicode/utf8"
+)
+
+// Punycode parameters, https://www.rfc-editor.org/rfc/rfc3492#section-5
+const (
+	punycodeBase        = 36
+	punycodeTMin        = 1
+	punycodeTMax        = 26
+	punycodeSkew        = 38
+	punycodeDamp        = 700
+	punycodeInitialBias = 72
+	punycodeInitialN    = 128
+
+	// maxLabelLength is the maximum length of a DNS label.
+	maxLabelLength = 63
+)
+
+// errInvalidLabel is returned for labels which cannot be encoded.
+var errInvalidLabel = errors.New("invalid host label")
+
+// labelSeparators are the dots IDNA maps to a full stop.
+var labelSeparators = strings.NewReplacer("。", ".", "．", ".", "｡", ".")
+
+// asciiHost returns the host in lowercase ASCII, with its internationalized labels punycode encoded
+// (e.g. "bücher.example" as "xn--bcher-kva.example"), so homograph hosts match the rules of their ASCII form.
+// This is a minimal normalizer: labels are lowercased but not fully IDNA mapped.
+// The port is kept. Returns the raw host if it cannot be converted.
+func asciiHost(host string) string {
+	hostname, port, splitError := net.SplitHostPort(host)
+	if splitError != nil {
+		hostname, port = host, ""
+	}
+
+	if !utf8.ValidString(hostname) {
+		return host
+	}
+
+	labels := strings.Split(labelSeparators.Replace(strings.ToLower(hostname)), ".")
+
+	for index, label := range labels {
+		if isASCII(label) {
+			continue
+		}
+
+		encoded, encodeError := punycodeEncode(label)
+		if encodeError != nil {
+			return host
+		}
+
+		labels[index] = "xn--" + encoded
+	}
+
+	hostname = strings.Join(labels, ".")
+	if port != "" {
+		return net.JoinHostPort(hostname, port)
+	}
+
+	return hostname
+}
+
+func isASCII(value string) bool {
+	for index := 0; index < len(value); index++ {
+		if value[index] >= utf8.RuneSelf {
+			return false
+		}
+	}
+
+	return true
+}
+
+// punycodeEncode encodes a label, https://www.rfc-editor.org/rfc/rfc3492#section-6.3
+func punycodeEncode(label string) (string, error) {
+	runes := []rune(label)
+	if len(runes) > maxLabelLength {
+		return "", errInvalidLabel
+	}
+
+	output := make([]byte, 0, maxLabelLength)
+
+	for _, character := range runes {
+		if character < utf8.RuneSelf {
+			output = append(output, byte(character))
+		}
+	}
+
+	basicCount := len(output)
+	handled := basicCount
+
+	if basicCount > 0 {
+		output = append(output, '-')
+	}
+
+	n, delta, bias := punycodeInitialN, 0, punycodeInitialBias
+
+	for handled < len(runes) {
+		next := int(utf8.MaxRune) + 1
+		for _, character := range runes {
+			if int(character) >= n && int(character) < next {
+				next = int(character)
+			}
+		}
+
+		delta += (next - n) * (handled + 1)
+		n = next
+
+		for _, character := range runes {
+			if int(character) < n {
+				delta++
+			}
+
+			if int(character) != n {
+				continue
+			}
+
+			q := delta
+
+			for k := punycodeBase; ; k += punycodeBase {
+				t := min(max(k-bias, punycodeTMin), punycodeTMax)
+				if q < t {
+					break
+				}
+
+				output = append(output, punycodeDigit(t+(q-t)%(punycodeBase-t)))
+				q = (q - t) / (punycodeBase - t)
+			}
+
+			output = append(output, punycodeDigit(q))
+			bias = punycodeAdapt(delta, handled+1, handled == basicCount)
+			delta = 0
+			handled++
+		}
+
+		delta++
+		n++
+	}
+
+	if len(output)+len("xn--") > maxLabelLength {
+		return "", errInvalidLabel
+	}
+
+	return string(output), nil
+}
+
+// punycodeAdapt is the bias adaptation function, https://www.rfc-editor.org/rfc/rfc3492#section-6.1
+func punycodeAdapt(delta int, numPoints int, firstTime bool) int {
+	if firstTime {
+		delta /= punycodeDamp
+	} else {
+		delta /= 2
+	}
+
+	delta += delta / numPoints
+
+	k := 0
+	for delta > ((punycodeBase-punycodeTMin)*punycodeTMax)/2 {
+		delta /= punycodeBase - punycodeTMin
+		k += punycodeBase
+	}
+
+	return k + (punycodeBase-punycodeTMin+1)*delta/(delta+punycodeSkew)
+}
+
+func punycodeDigit(digit int) byte {
+	if digit < 26 {
+		return byte('a' + digit)
+	}
+
+	return byte('0' + digit - 26)
+}
@@ -0,0 +1,56 @@
+package traefik_block_regex_urls
+
+import "sync"
+
+// recentBlocks is a ring buffer of the last block events, overwriting the oldest when full.
+type recentBlocks struct {
+	mu     sync.Mutex
+	events []BlockEvent
+	next   int
+	full   bool
+}
+
+func newRecentBlocks(capacity int) *recentBlocks {
+	return &recentBlocks{events: make([]BlockEvent, capacity)}
+}
+
+func (ring *recentBlocks) add(event BlockEvent) {
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+
+	ring.events[ring.next] = event
+	ring.next = (ring.next + 1) % len(ring.events)
+	ring.full = ring.full || ring.next == 0
+}
+
+// last returns the n most recent events, most recent first. A negative n returns all of them.
+func (ring *recentBlocks) last(n int) []BlockEvent {
+	ring.mu.Lock()
+	defer ring.mu.Unlock()
+
+	size := ring.next
+	if ring.full {
+		size = len(ring.events)
+	}
+
+	if n < 0 || n > size {
+		n = size
+	}
+
+	last := make([]BlockEvent, n)
+	for index := range last {
+		last[index] = ring.events[(ring.next-1-index+len(ring.events))%len(ring.events)]
+	}
+
+	return last
+}
+
+// RecentBlocks returns the n most recent blocked requests, most recent first.
+// Returns nil if recentBlocksCapacity is not set.
+func (blockUrls *traefik_block_regex_urls) RecentBlocks(n int) []BlockEvent {
+	if blockUrls.recentBlocks == nil {
+		return nil
+	}
+
+	return blockUrls.recentBlocks.last(n)
+}
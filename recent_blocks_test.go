@@ -0,0 +1,76 @@
+package traefik_block_regex_urls_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+type recentBlocksReporter interface {
+	RecentBlocks(n int) []BlockUrls.BlockEvent
+}
+
+func Test_BlockUrls_RecentBlocks_WrapsAndReturnsMostRecent(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/probe(.*)"}
+	cfg.RecentBlocksCapacity = 3
+
+	handler := newHandler(t, cfg)
+	reporter := handler.(recentBlocksReporter)
+
+	if recent := reporter.RecentBlocks(10); len(recent) != 0 {
+		t.Errorf("expected no recent blocks, got %v", recent)
+	}
+
+	for _, url := range []string{"/probe-1", "/probe-2", "/index.html", "/probe-3", "/probe-4", "/probe-5"} {
+		serveRequest(t, handler, "http://localhost"+url)
+	}
+
+	recent := reporter.RecentBlocks(10)
+	if len(recent) != 3 {
+		t.Fatalf("expected 3 recent blocks, got %v", recent)
+	}
+
+	for index, expected := range []string{"localhost/probe-5", "localhost/probe-4", "localhost/probe-3"} {
+		if recent[index].URL != expected || recent[index].Reason != "regex match" {
+			t.Errorf("unexpected recent block %d: %+v", index, recent[index])
+		}
+	}
+
+	if recent := reporter.RecentBlocks(1); len(recent) != 1 || recent[0].URL != "localhost/probe-5" {
+		t.Errorf("unexpected last recent block: %v", recent)
+	}
+}
+
+func Test_BlockUrls_RecentBlocks_ServedOnStatusPath(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/probe(.*)"}
+	cfg.RecentBlocksCapacity = 3
+	cfg.StatusPath = "/__block_status"
+	cfg.AllowedIPs = []string{"10.0.0.0/8"}
+
+	handler, err := BlockUrls.New(context.Background(), nil, cfg, "BlockUrls")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serveRequestWithHeaders(t, handler, "http://localhost/probe-1", map[string]string{"X-Forwarded-For": "2.56.20.1"})
+
+	response := serveRequestWithHeaders(t, handler, "http://localhost/__block_status", map[string]string{"X-Forwarded-For": "10.0.0.1"})
+	assertStatusCode(t, response, http.StatusOK)
+
+	var status struct {
+		RecentBlocks []BlockUrls.BlockEvent `json:"recentBlocks"`
+	}
+
+	if err := json.NewDecoder(response.Body).Decode(&status); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(status.RecentBlocks) != 1 || status.RecentBlocks[0].IP != "2.56.20.1" || status.RecentBlocks[0].URL != "localhost/probe-1" {
+		t.Errorf("unexpected recent blocks: %+v", status.RecentBlocks)
+	}
+}
@@ -0,0 +1,311 @@
+package traefik_block_regex_urls
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// defaultMaxForwardedIPs bounds the number of X-Forwarded-For entries parsed per request.
+const defaultMaxForwardedIPs = 20
+
+// InitializePrivateIPBlocks returns the private, loopback and link-local ranges.
+// https://en.wikipedia.org/wiki/Private_network
+func InitializePrivateIPBlocks() []*net.IPNet {
+	return InitializePrivateIPBlocksWith(PrivateRanges{IncludeIPv6ULA: true, IncludeLinkLocal: true})
+}
+
+// PrivateRanges selects the optional ranges of InitializePrivateIPBlocksWith.
+// Loopback and RFC1918 ranges are always included.
+type PrivateRanges struct {
+	// IncludeIPv6ULA includes the IPv6 unique local addresses (fc00::/7).
+	IncludeIPv6ULA bool
+	// IncludeLinkLocal includes the IPv4 and IPv6 link-local ranges (169.254.0.0/16, fe80::/10).
+	IncludeLinkLocal bool
+}
+
+// InitializePrivateIPBlocksWith returns the loopback and RFC1918 ranges, and the selected optional ranges.
+func InitializePrivateIPBlocksWith(ranges PrivateRanges) []*net.IPNet {
+	privateCIDRs := []string{
+		"127.0.0.0/8",    // IPv4 loopback
+		"10.0.0.0/8",     // RFC1918
+		"172.16.0.0/12",  // RFC1918
+		"192.168.0.0/16", // RFC1918
+		"::1/128",        // IPv6 loopback
+	}
+
+	if ranges.IncludeLinkLocal {
+		privateCIDRs = append(privateCIDRs,
+			"169.254.0.0/16", // RFC3927 link-local
+			"fe80::/10",      // IPv6 link-local
+		)
+	}
+
+	if ranges.IncludeIPv6ULA {
+		privateCIDRs = append(privateCIDRs, "fc00::/7") // IPv6 unique local addr
+	}
+
+	privateIPBlocks := make([]*net.IPNet, 0, len(privateCIDRs))
+
+	for _, cidr := range privateCIDRs {
+		_, block, parseError := net.ParseCIDR(cidr)
+		if parseError != nil {
+			panic(fmt.Errorf("parse error on %q: %w", cidr, parseError))
+		}
+
+		privateIPBlocks = append(privateIPBlocks, block)
+	}
+
+	return privateIPBlocks
+}
+
+// CollectRemoteIP returns the client IPs announced by the X-Forwarded-For, Forwarded (RFC 7239) and X-Real-Ip headers, in that order.
+// At most maxForwardedIPs entries of X-Forwarded-For and Forwarded are parsed, the rest is dropped.
+// A validly signed trustedIPHeader replaces them all.
+func (blockUrls *traefik_block_regex_urls) CollectRemoteIP(request *http.Request) []net.IP {
+	if blockUrls.trustedIPHeader != nil {
+		if trustedIP := blockUrls.trustedIPHeader.ip(request); trustedIP != nil {
+			return []net.IP{trustedIP}
+		}
+	}
+
+	remoteIPs := []net.IP{}
+	parsedEntries := 0
+
+	forwardedFor := strings.Join(request.Header.Values("X-Forwarded-For"), ",")
+
+	for forwardedFor != "" {
+		if parsedEntries == blockUrls.maxForwardedIPs {
+			blockUrls.logger.Printf("X-Forwarded-For truncated after %d entries: middleware=%s", parsedEntries, blockUrls.name)
+			break
+		}
+
+		var entry string
+		entry, forwardedFor, _ = strings.Cut(forwardedFor, ",")
+
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parsedEntries++
+
+		if remoteIP := net.ParseIP(entry); remoteIP != nil {
+			remoteIPs = append(remoteIPs, remoteIP)
+		}
+	}
+
+	forwarded := strings.Join(request.Header.Values("Forwarded"), ",")
+
+	for forwarded != "" {
+		if parsedEntries == blockUrls.maxForwardedIPs {
+			blockUrls.logger.Printf("Forwarded truncated after %d entries: middleware=%s", parsedEntries, blockUrls.name)
+			break
+		}
+
+		var element string
+		element, forwarded, _ = strings.Cut(forwarded, ",")
+
+		if strings.TrimSpace(element) == "" {
+			continue
+		}
+
+		parsedEntries++
+
+		if remoteIP := parseForwardedFor(element); remoteIP != nil {
+			remoteIPs = append(remoteIPs, remoteIP)
+		}
+	}
+
+	if remoteIP := net.ParseIP(strings.TrimSpace(request.Header.Get("X-Real-Ip"))); remoteIP != nil {
+		remoteIPs = append(remoteIPs, remoteIP)
+	}
+
+	return remoteIPs
+}
+
+// parseForwardedFor returns the ip of the for= parameter of a Forwarded element, e.g. `for=192.0.2.60;proto=http`.
+// Handles quoted values with ports and bracketed IPv6, e.g. `for="[2001:db8:cafe::17]:4711"`.
+// Returns nil for obfuscated identifiers like `for=unknown` or `for=_hidden`.
+func parseForwardedFor(element string) net.IP {
+	for _, pair := range strings.Split(element, ";") {
+		key, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+		if !found || !strings.EqualFold(key, "for") {
+			continue
+		}
+
+		value = strings.Trim(strings.TrimSpace(value), `"`)
+
+		if strings.HasPrefix(value, "[") {
+			// bracketed IPv6, optionally followed by a port
+			if end := strings.Index(value, "]"); end > 0 {
+				return net.ParseIP(value[1:end])
+			}
+
+			return nil
+		}
+
+		if host, _, splitError := net.SplitHostPort(value); splitError == nil {
+			value = host
+		}
+
+		return net.ParseIP(value)
+	}
+
+	return nil
+}
+
+// remoteIP returns the client ip: a validly signed trustedIPHeader, the X-Forwarded-For entry at forwardedIPDepth if set,
+// otherwise the first collected remote ip. Returns nil if there is none.
+func (blockUrls *traefik_block_regex_urls) remoteIP(request *http.Request) net.IP {
+	if blockUrls.trustedIPHeader != nil {
+		if trustedIP := blockUrls.trustedIPHeader.ip(request); trustedIP != nil {
+			return trustedIP
+		}
+	}
+
+	if blockUrls.forwardedIPDepth > 0 {
+		return forwardedIPAtDepth(request, blockUrls.forwardedIPDepth)
+	}
+
+	remoteIPs := blockUrls.CollectRemoteIP(request)
+	if len(remoteIPs) == 0 {
+		return nil
+	}
+
+	return remoteIPs[0]
+}
+
+// forwardedIPAtDepth returns the ip of the X-Forwarded-For entry at depth from the right, like the ipStrategy.depth
+// of Traefik, e.g. 12.0.0.1 for "10.0.0.1, 11.0.0.1, 12.0.0.1, 13.0.0.1" at depth 2. The entries left of it can be
+// spoofed by the client. Returns nil if the chain is shorter than depth, or the entry is not an ip.
+func forwardedIPAtDepth(request *http.Request, depth int) net.IP {
+	forwardedFor := strings.Join(request.Header.Values("X-Forwarded-For"), ",")
+
+	for entries := 1; ; entries++ {
+		separator := strings.LastIndex(forwardedFor, ",")
+
+		if entries == depth {
+			return net.ParseIP(strings.TrimSpace(forwardedFor[separator+1:]))
+		}
+
+		if separator < 0 {
+			return nil
+		}
+
+		forwardedFor = forwardedFor[:separator]
+	}
+}
+
+// requestClient is the client ip of a request, resolved on first use and kept for the other checks of the request,
+// so the forwarding headers are parsed once per request.
+type requestClient struct {
+	blockUrls *traefik_block_regex_urls
+	request   *http.Request
+
+	resolved bool
+	ip       net.IP
+	address  string
+}
+
+func (blockUrls *traefik_block_regex_urls) newRequestClient(request *http.Request) *requestClient {
+	return &requestClient{blockUrls: blockUrls, request: request}
+}
+
+// remoteIP returns the client ip, see remoteIP of the plugin. Returns nil if there is none.
+func (client *requestClient) remoteIP() net.IP {
+	if !client.resolved {
+		client.ip = client.blockUrls.remoteIP(client.request)
+		if client.ip != nil {
+			client.address = client.ip.String()
+		}
+
+		client.resolved = true
+	}
+
+	return client.ip
+}
+
+// clientIP returns the client ip as a string, or an empty string if none.
+func (client *requestClient) clientIP() string {
+	client.remoteIP()
+
+	return client.address
+}
+
+// isPrivateIP reports whether the ip belongs to one of the private ranges.
+func (blockUrls *traefik_block_regex_urls) isPrivateIP(ip net.IP) bool {
+	return containsIP(blockUrls.privateIPBlocks, ip)
+}
+
+// isLocalRequest reports whether the client ip is in a private range.
+func (blockUrls *traefik_block_regex_urls) isLocalRequest(client *requestClient) bool {
+	remoteIP := client.remoteIP()
+
+	return remoteIP != nil && blockUrls.isPrivateIP(remoteIP)
+}
+
+// parseIPNets parses a list of CIDRs or plain ips, the latter as single-address networks.
+func parseIPNets(values []string) ([]*net.IPNet, error) {
+	ipNets := make([]*net.IPNet, 0, len(values))
+
+	for _, value := range values {
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid ip %q", value)
+			}
+
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+
+			ipNets = append(ipNets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, ipNet, parseError := net.ParseCIDR(value)
+		if parseError != nil {
+			return nil, fmt.Errorf("invalid cidr %q: %w", value, parseError)
+		}
+
+		ipNets = append(ipNets, ipNet)
+	}
+
+	return ipNets, nil
+}
+
+// containsIP reports whether any of the networks contains the ip.
+func containsIP(ipNets []*net.IPNet, ip net.IP) bool {
+	for _, ipNet := range ipNets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isAllowedRequest reports whether the client ip is in the ip allowlist of the status and debug endpoints.
+func (blockUrls *traefik_block_regex_urls) isAllowedRequest(client *requestClient) bool {
+	return remoteIPIn(client, blockUrls.allowedIPs)
+}
+
+// isBypassedRequest reports whether the client ip is in the ip list which bypasses blocking.
+func (blockUrls *traefik_block_regex_urls) isBypassedRequest(client *requestClient) bool {
+	return remoteIPIn(client, blockUrls.bypassIPs)
+}
+
+// remoteIPIn reports whether the client ip is in any of the networks.
+func remoteIPIn(client *requestClient, ipNets []*net.IPNet) bool {
+	if len(ipNets) == 0 {
+		return false
+	}
+
+	remoteIP := client.remoteIP()
+
+	return remoteIP != nil && containsIP(ipNets, remoteIP)
+}
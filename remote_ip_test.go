@@ -0,0 +1,316 @@
+package traefik_block_regex_urls_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+type remoteIPCollector interface {
+	CollectRemoteIP(request *http.Request) []net.IP
+}
+
+func Test_BlockUrls_CollectRemoteIP_TruncatesOversizedForwardedFor(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+
+	handler := newHandler(t, cfg)
+
+	forwardedIPs := make([]string, 5000)
+	for index := range forwardedIPs {
+		forwardedIPs[index] = fmt.Sprintf("2.56.%d.%d", index/256%256, index%256)
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://localhost/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Add("X-Forwarded-For", strings.Join(forwardedIPs, ", "))
+	req.Header.Add("X-Real-Ip", "2.56.20.0")
+
+	remoteIPs := handler.(remoteIPCollector).CollectRemoteIP(req)
+
+	if len(remoteIPs) != 21 {
+		t.Fatalf("expected 20 forwarded ips and the real ip, got %d", len(remoteIPs))
+	}
+
+	if !remoteIPs[0].Equal(net.ParseIP("2.56.0.0")) || !remoteIPs[20].Equal(net.ParseIP("2.56.20.0")) {
+		t.Errorf("unexpected remote ips: %v", remoteIPs)
+	}
+}
+
+func Test_BlockUrls_CollectRemoteIP_LogsTruncationOncePerRequest(t *testing.T) {
+	var output bytes.Buffer
+
+	log.SetOutput(&output)
+	defer log.SetOutput(os.Stderr)
+
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login"}
+	cfg.MaxForwardedIPs = 2
+	cfg.TrackTopBlockedIPs = true
+	cfg.AllowLocalRequests = true
+	cfg.BypassIPs = []string{"10.0.0.1"}
+	cfg.MinInterval = "1ms"
+	cfg.DistinctURLThreshold = 100
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	handler, err := BlockUrls.New(context.Background(), next, cfg, "BlockUrls")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	headers := map[string]string{"X-Forwarded-For": "2.56.20.1, 2.56.20.2, 2.56.20.3"}
+
+	for i := 0; i < 3; i++ {
+		serveRequestWithHeaders(t, handler, "http://localhost/wp-login", headers)
+	}
+
+	// every check of the request and the block share one resolved client ip
+	if count := strings.Count(output.String(), "X-Forwarded-For truncated"); count != 3 {
+		t.Errorf("expected the truncation to be logged once per request, got %d times in %q", count, output.String())
+	}
+}
+
+func Test_BlockUrls_CollectRemoteIP_HonorsMaxForwardedIPs(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.MaxForwardedIPs = 2
+
+	handler := newHandler(t, cfg)
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://localhost/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Add("X-Forwarded-For", "2.56.20.1, not-an-ip, 2.56.20.3")
+	req.Header.Add("X-Forwarded-For", "2.56.20.4")
+
+	remoteIPs := handler.(remoteIPCollector).CollectRemoteIP(req)
+
+	if len(remoteIPs) != 1 || !remoteIPs[0].Equal(net.ParseIP("2.56.20.1")) {
+		t.Errorf("unexpected remote ips: %v", remoteIPs)
+	}
+}
+
+func Test_BlockUrls_CollectRemoteIP_ParsesForwarded(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+
+	handler := newHandler(t, cfg)
+
+	// examples of RFC 7239, section 4 and 7.4
+	tests := map[string][]string{
+		`for="_gazonk"`:                                   {},
+		`For="[2001:db8:cafe::17]:4711"`:                  {"2001:db8:cafe::17"},
+		`for=192.0.2.60;proto=http;by=203.0.113.43`:       {"192.0.2.60"},
+		`for=192.0.2.43, for=198.51.100.17`:               {"192.0.2.43", "198.51.100.17"},
+		`for="192.0.2.43:47011"`:                          {"192.0.2.43"},
+		`for="[2001:db8:cafe::17]"`:                       {"2001:db8:cafe::17"},
+		`for=unknown, for="[2001:db8::1]:80";proto=https`: {"2001:db8::1"},
+		`proto=https;for=198.51.100.17;host=example.com`:  {"198.51.100.17"},
+	}
+
+	for forwarded, expected := range tests {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://localhost/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Add("Forwarded", forwarded)
+
+		remoteIPs := handler.(remoteIPCollector).CollectRemoteIP(req)
+
+		if len(remoteIPs) != len(expected) {
+			t.Errorf("%s: unexpected remote ips: %v", forwarded, remoteIPs)
+			continue
+		}
+
+		for index, ip := range expected {
+			if !remoteIPs[index].Equal(net.ParseIP(ip)) {
+				t.Errorf("%s: unexpected remote ip %v <> %s", forwarded, remoteIPs[index], ip)
+			}
+		}
+	}
+}
+
+func Test_BlockUrls_AllowLocalRequests(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp(.*)"}
+	cfg.AllowLocalRequests = true
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	tests := map[string]int{
+		"192.168.1.1":           http.StatusOK,
+		"10.0.0.1, 2.56.20.0":   http.StatusOK,
+		"fd00::1":               http.StatusOK,
+		"2.56.20.0":             http.StatusNotFound,
+		"2.56.20.0, 10.0.0.1":   http.StatusNotFound,
+		"2001:db8::1, 10.0.0.1": http.StatusNotFound,
+	}
+
+	for forwardedFor, expected := range tests {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://localhost/wp-login", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Add("X-Forwarded-For", forwardedFor)
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+
+		assertStatusCode(t, recorder.Result(), expected)
+	}
+}
+
+func Test_BlockUrls_AllowLocalRequests_TogglesOptionalRanges(t *testing.T) {
+	tests := []struct {
+		includeIPv6ULA   bool
+		includeLinkLocal bool
+		expected         map[string]int
+	}{
+		{true, true, map[string]int{"fd00::1": http.StatusOK, "169.254.1.1": http.StatusOK, "fe80::1": http.StatusOK, "10.0.0.1": http.StatusOK}},
+		{false, true, map[string]int{"fd00::1": http.StatusNotFound, "169.254.1.1": http.StatusOK, "fe80::1": http.StatusOK, "10.0.0.1": http.StatusOK}},
+		{true, false, map[string]int{"fd00::1": http.StatusOK, "169.254.1.1": http.StatusNotFound, "fe80::1": http.StatusNotFound, "10.0.0.1": http.StatusOK}},
+	}
+
+	for _, test := range tests {
+		cfg := BlockUrls.CreateConfig()
+		cfg.Regex = []string{"(.*)/wp(.*)"}
+		cfg.AllowLocalRequests = true
+		cfg.IncludeIPv6ULA = test.includeIPv6ULA
+		cfg.IncludeLinkLocal = test.includeLinkLocal
+		cfg.StatusCode = 404
+
+		handler := newHandler(t, cfg)
+
+		for forwardedFor, expected := range test.expected {
+			response := serveRequestWithHeaders(t, handler, "http://localhost/wp-login", map[string]string{"X-Forwarded-For": forwardedFor})
+			if response.StatusCode != expected {
+				t.Errorf("ula=%t linkLocal=%t %s: unexpected status code %d, expected %d",
+					test.includeIPv6ULA, test.includeLinkLocal, forwardedFor, response.StatusCode, expected)
+			}
+		}
+	}
+}
+
+func Test_BlockUrls_ForwardedIPDepth(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp(.*)"}
+	cfg.BypassIPs = []string{"12.0.0.1"}
+	cfg.ForwardedIPDepth = 2
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	tests := map[string]int{
+		// the spoofed leftmost entry is ignored, two trusted proxies appended their peer
+		"12.0.0.1, 2.56.20.0, 13.0.0.1":          http.StatusNotFound,
+		"10.0.0.1, 11.0.0.1, 12.0.0.1, 13.0.0.1": http.StatusOK,
+		"12.0.0.1,13.0.0.1":                      http.StatusOK,
+		// shorter than the depth, there is no client ip
+		"12.0.0.1": http.StatusNotFound,
+	}
+
+	for forwardedFor, expected := range tests {
+		assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/wp-login", map[string]string{"X-Forwarded-For": forwardedFor}), expected)
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://localhost/wp-login", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the entries of repeated headers are counted across headers
+	req.Header.Add("X-Forwarded-For", "2.56.20.0, 12.0.0.1")
+	req.Header.Add("X-Forwarded-For", "13.0.0.1")
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+
+	assertStatusCode(t, recorder.Result(), http.StatusOK)
+}
+
+func Test_BlockUrls_TrustedIPHeader(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp(.*)"}
+	cfg.BypassIPs = []string{"12.0.0.1"}
+	cfg.TrustedIPHeader = "X-Client-IP"
+	cfg.TrustedIPSignatureHeader = "X-Client-IP-Signature"
+	cfg.TrustedIPSecret = "s3cret"
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	sign := func(value string, secret string) string {
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write([]byte(value))
+
+		return hex.EncodeToString(mac.Sum(nil))
+	}
+
+	tests := []struct {
+		desc     string
+		headers  map[string]string
+		expected int
+	}{
+		{
+			desc:     "valid signature",
+			headers:  map[string]string{"X-Client-IP": "12.0.0.1", "X-Client-IP-Signature": sign("12.0.0.1", "s3cret"), "X-Forwarded-For": "2.56.20.0"},
+			expected: http.StatusOK,
+		},
+		{
+			desc:     "wrong secret",
+			headers:  map[string]string{"X-Client-IP": "12.0.0.1", "X-Client-IP-Signature": sign("12.0.0.1", "guess")},
+			expected: http.StatusNotFound,
+		},
+		{
+			desc:     "signature of another ip",
+			headers:  map[string]string{"X-Client-IP": "12.0.0.1", "X-Client-IP-Signature": sign("2.56.20.0", "s3cret")},
+			expected: http.StatusNotFound,
+		},
+		{
+			desc:     "missing signature",
+			headers:  map[string]string{"X-Client-IP": "12.0.0.1"},
+			expected: http.StatusNotFound,
+		},
+		{
+			desc:     "invalid signature falls back to the forwarded ip",
+			headers:  map[string]string{"X-Client-IP": "2.56.20.0", "X-Client-IP-Signature": "not-hex", "X-Forwarded-For": "12.0.0.1"},
+			expected: http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/wp-login", test.headers), test.expected)
+		})
+	}
+}
+
+func Test_BlockUrls_TrustedIPHeader_RequiresSecret(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.TrustedIPHeader = "X-Client-IP"
+	cfg.TrustedIPSignatureHeader = "X-Client-IP-Signature"
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	if _, err := BlockUrls.New(context.Background(), next, cfg, "BlockUrls"); err == nil {
+		t.Fatal("expected an error for a trusted ip header without secret")
+	}
+}
@@ -0,0 +1,285 @@
+package traefik_block_regex_urls
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// matchRequestAnomalies checks the request for malformed or malicious properties which no legitimate client sends.
+// Returns the block reason, or an empty string if the request looks sane.
+func (blockUrls *traefik_block_regex_urls) matchRequestAnomalies(request *http.Request) string {
+	if blockUrls.blockControlChars && containsControlChar(request.URL.Path) {
+		return "control character in path"
+	}
+
+	// the path is percent-decoded, so %C0%AF and the like show up as invalid bytes here
+	if blockUrls.blockInvalidUTF8Path && !utf8.ValidString(request.URL.Path) {
+		return "invalid utf-8 in path"
+	}
+
+	if blockUrls.blockSmugglingIndicators && hasSmugglingIndicators(request) {
+		return "request smuggling indicator"
+	}
+
+	if blockUrls.blockSNIHostMismatch && hasSNIHostMismatch(request) {
+		return "sni host mismatch"
+	}
+
+	if len(blockUrls.expectedHosts) > 0 && !isExpectedHost(request.Host, blockUrls.expectedHosts) {
+		return "unexpected host"
+	}
+
+	if len(blockUrls.blockedTLDs) > 0 && slices.Contains(blockUrls.blockedTLDs, hostTLD(request.Host)) {
+		return "blocked tld"
+	}
+
+	if blockUrls.expectHTTPS && !blockUrls.expectHTTPSRedirect && requestScheme(request) == "http" {
+		return "scheme downgrade"
+	}
+
+	if blockUrls.blockMixedCasePath && isMixedCasePath(request.URL.Path, blockUrls.mixedCaseMaxPercent) {
+		return "mixed case path"
+	}
+
+	if blockUrls.maxTraversalDepth > 0 && countTraversals(request.URL.Path) > blockUrls.maxTraversalDepth {
+		return "path traversal"
+	}
+
+	if blockUrls.blockHighEntropyPath && isHighEntropyPath(request.URL.Path, blockUrls.pathEntropyMinLength, blockUrls.pathEntropyThreshold) {
+		return "high entropy path"
+	}
+
+	return ""
+}
+
+// defaultMixedCaseMaxPercent allows camelCase segments like getUserById (60%), and blocks wPaDmIn (100%).
+// WpAdmin (50%) looks like any PascalCase name and passes, catching it takes a limit below 50 which blocks camelCase too.
+const defaultMixedCaseMaxPercent = 70
+
+// mixedCaseMinLetters is the number of letters below which a path segment is too short to judge.
+const mixedCaseMinLetters = 5
+
+// isMixedCasePath reports whether a path segment changes case between more than maxPercent of its adjacent
+// ASCII letters, e.g. 100% for /wPaDmIn, 50% for /WpAdmin. Other characters are skipped.
+func isMixedCasePath(path string, maxPercent int) bool {
+	for _, segment := range strings.Split(path, "/") {
+		letters, transitions := 0, 0
+		var previousUpper bool
+
+		for index := 0; index < len(segment); index++ {
+			character := segment[index]
+
+			isUpper := character >= 'A' && character <= 'Z'
+			if !isUpper && (character < 'a' || character > 'z') {
+				continue
+			}
+
+			if letters > 0 && isUpper != previousUpper {
+				transitions++
+			}
+
+			letters++
+			previousUpper = isUpper
+		}
+
+		if letters >= mixedCaseMinLetters && transitions*100 > maxPercent*(letters-1) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// containsControlChar reports whether the value contains an ASCII control character (0x00-0x1f, 0x7f).
+// Multi-byte UTF-8 sequences only use bytes >= 0x80 and never match.
+func containsControlChar(value string) bool {
+	for index := 0; index < len(value); index++ {
+		if value[index] < 0x20 || value[index] == 0x7f {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasSmugglingIndicators reports whether the request carries several Content-Length or Transfer-Encoding headers,
+// or both a Content-Length and a Transfer-Encoding.
+// The net/http server already rejects differing Content-Length values, collapses identical ones and drops
+// Content-Length when the body is chunked, so this mostly catches requests passed on by other servers or handlers.
+func hasSmugglingIndicators(request *http.Request) bool {
+	contentLengths := request.Header.Values("Content-Length")
+	if len(contentLengths) > 1 {
+		return true
+	}
+
+	transferEncodings := request.Header.Values("Transfer-Encoding")
+	if len(transferEncodings) > 1 {
+		return true
+	}
+
+	hasTransferEncoding := len(transferEncodings) > 0 || len(request.TransferEncoding) > 0
+
+	return hasTransferEncoding && len(contentLengths) > 0
+}
+
+// hasSNIHostMismatch reports whether the TLS server name differs from the Host header, ignoring case and port,
+// as sent by domain fronting clients. Plain HTTP requests and TLS requests without SNI never mismatch.
+func hasSNIHostMismatch(request *http.Request) bool {
+	if request.TLS == nil || request.TLS.ServerName == "" {
+		return false
+	}
+
+	host := request.Host
+	if hostname, _, splitError := net.SplitHostPort(host); splitError == nil {
+		host = hostname
+	}
+
+	return !strings.EqualFold(strings.TrimSuffix(host, "."), strings.TrimSuffix(request.TLS.ServerName, "."))
+}
+
+// requestScheme returns the scheme the client used, as announced by the first proxy in X-Forwarded-Proto or
+// the Forwarded (RFC 7239) proto parameter, or else the scheme of the connection.
+func requestScheme(request *http.Request) string {
+	if forwardedProto := request.Header.Get("X-Forwarded-Proto"); forwardedProto != "" {
+		proto, _, _ := strings.Cut(forwardedProto, ",")
+		return strings.ToLower(strings.TrimSpace(proto))
+	}
+
+	if forwarded := request.Header.Get("Forwarded"); forwarded != "" {
+		element, _, _ := strings.Cut(forwarded, ",")
+
+		for _, pair := range strings.Split(element, ";") {
+			key, value, found := strings.Cut(strings.TrimSpace(pair), "=")
+			if found && strings.EqualFold(key, "proto") {
+				return strings.ToLower(strings.Trim(strings.TrimSpace(value), `"`))
+			}
+		}
+	}
+
+	if request.TLS != nil {
+		return "https"
+	}
+
+	return "http"
+}
+
+// redirectToHTTPS redirects the request to the same url over https, keeping the method and body (308).
+func redirectToHTTPS(responseWriter http.ResponseWriter, request *http.Request) {
+	http.Redirect(responseWriter, request, "https://"+request.Host+request.URL.RequestURI(), http.StatusPermanentRedirect)
+}
+
+// isExpectedHost reports whether the host, without port and trailing dot and ignoring case, is one of the expected hosts.
+// An expected host like "*.example.com" matches any subdomain of example.com, but not example.com itself.
+func isExpectedHost(host string, expectedHosts []string) bool {
+	host = strings.ToLower(strings.TrimSuffix(hostname(host), "."))
+
+	for _, expected := range expectedHosts {
+		expected = strings.ToLower(expected)
+
+		if suffix, isWildcard := strings.CutPrefix(expected, "*"); isWildcard {
+			if strings.HasSuffix(host, suffix) && len(host) > len(suffix) {
+				return true
+			}
+
+			continue
+		}
+
+		if host == expected {
+			return true
+		}
+	}
+
+	return false
+}
+
+// normalizeTLDs returns the TLDs lowercased, in ASCII form and without dots, e.g. ".Onion" as "onion".
+func normalizeTLDs(tlds []string) []string {
+	normalized := make([]string, 0, len(tlds))
+
+	for _, tld := range tlds {
+		if tld = strings.Trim(tld, "."); tld != "" {
+			normalized = append(normalized, asciiHost(tld))
+		}
+	}
+
+	return normalized
+}
+
+// hostTLD returns the lowercased ASCII top-level domain of the host, e.g. "xn--p1ai" for "пример.рф:8080".
+// Hosts without a dot (e.g. "localhost") and IP hosts have none, an empty string is returned.
+func hostTLD(host string) string {
+	name := strings.TrimSuffix(hostname(asciiHost(host)), ".")
+	if net.ParseIP(strings.Trim(name, "[]")) != nil {
+		return ""
+	}
+
+	dot := strings.LastIndexByte(name, '.')
+	if dot < 0 {
+		return ""
+	}
+
+	return name[dot+1:]
+}
+
+// defaultPathEntropyThreshold is above hex digests and uuids (at most 4 bits per character),
+// and below random base62 strings of the default minimum length.
+const defaultPathEntropyThreshold = 4.2
+
+// defaultPathEntropyMinLength is the length below which a path segment is too short to judge.
+const defaultPathEntropyMinLength = 20
+
+// isHighEntropyPath reports whether the longest path segment has at least minLength bytes and a Shannon entropy
+// above threshold bits per byte, as random probe paths like /aZ8kQ2xLm9Pw4RtY7vBn3Hc6 do.
+func isHighEntropyPath(path string, minLength int, threshold float64) bool {
+	longest := ""
+
+	for _, segment := range strings.Split(path, "/") {
+		if len(segment) > len(longest) {
+			longest = segment
+		}
+	}
+
+	if len(longest) < minLength {
+		return false
+	}
+
+	return shannonEntropy(longest) > threshold
+}
+
+// shannonEntropy returns the entropy of the byte distribution of the value, in bits per byte.
+func shannonEntropy(value string) float64 {
+	var counts [256]int
+
+	for index := 0; index < len(value); index++ {
+		counts[value[index]]++
+	}
+
+	entropy := 0.0
+	length := float64(len(value))
+
+	for _, count := range counts {
+		if count == 0 {
+			continue
+		}
+
+		probability := float64(count) / length
+		entropy -= probability * math.Log2(probability)
+	}
+
+	return entropy
+}
+
+// traversalDecoder decodes the dots and slashes of a double encoded traversal, e.g. %252e%252e%252f,
+// which is %2e%2e%2f once decoded by net/http.
+var traversalDecoder = strings.NewReplacer("%2e", ".", "%2f", "/", "%5c", `\`)
+
+// countTraversals counts the "../" and `..\` sequences of a decoded path, including double encoded ones.
+func countTraversals(path string) int {
+	path = traversalDecoder.Replace(strings.ToLower(path))
+
+	return strings.Count(path, "../") + strings.Count(path, `..\`)
+}
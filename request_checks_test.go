@@ -0,0 +1,331 @@
+package traefik_block_regex_urls_test
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+func Test_BlockUrls_BlockControlChars(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.BlockControlChars = true
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/index.php%00.jpg"), http.StatusNotFound)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/redirect%0d%0aSet-Cookie:x=1"), http.StatusNotFound)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/index.html"), http.StatusOK)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/caf%C3%A9/%E6%97%A5%E6%9C%AC"), http.StatusOK)
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://localhost/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a raw newline cannot be parsed by url.Parse, so it is injected into the parsed path
+	req.URL.Path = "/admin\nX-Injected: 1"
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+
+	assertStatusCode(t, recorder.Result(), http.StatusNotFound)
+}
+
+func Test_BlockUrls_AllowsControlChars_IfDisabled(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/index.php%00.jpg"), http.StatusOK)
+}
+
+func Test_BlockUrls_BlockInvalidUTF8Path(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/..%C0%AF..%C0%AFetc/passwd"), http.StatusOK)
+
+	cfg.BlockInvalidUTF8Path = true
+
+	handler = newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/..%C0%AF..%C0%AFetc/passwd"), http.StatusNotFound)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/admin%FF"), http.StatusNotFound)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/caf%C3%A9/%E6%97%A5%E6%9C%AC"), http.StatusOK)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/index.html"), http.StatusOK)
+}
+
+func Test_BlockUrls_ExpectedHosts(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.ExpectedHosts = []string{"example.com", "*.example.org"}
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	tests := map[string]int{
+		"http://example.com/":          http.StatusOK,
+		"http://Example.COM:8443/":     http.StatusOK,
+		"http://example.com./":         http.StatusOK,
+		"http://www.example.org/":      http.StatusOK,
+		"http://a.b.example.org/":      http.StatusOK,
+		"http://example.org/":          http.StatusNotFound,
+		"http://evil.com/":             http.StatusNotFound,
+		"http://www.example.com/":      http.StatusNotFound,
+		"http://notexample.org/":       http.StatusNotFound,
+		"http://example.com.evil.com/": http.StatusNotFound,
+		"http://127.0.0.1/":            http.StatusNotFound,
+	}
+
+	for url, expected := range tests {
+		assertStatusCode(t, serveRequest(t, handler, url), expected)
+	}
+}
+
+func Test_BlockUrls_BlockedTLDs(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.BlockedTLDs = []string{".onion", "ZIP", "рф"}
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	tests := map[string]int{
+		"http://expyuzz4wqqyqhjn.onion/": http.StatusNotFound,
+		"http://Login.Bank.ZIP:8443/":    http.StatusNotFound,
+		"http://shop.zip./":              http.StatusNotFound,
+		"http://пример.рф/":              http.StatusNotFound,
+		"http://xn--e1afmkfd.xn--p1ai/":  http.StatusNotFound,
+		"http://example.com/":            http.StatusOK,
+		"http://onion.example.com/":      http.StatusOK,
+		"http://onion/":                  http.StatusOK,
+		"http://localhost/":              http.StatusOK,
+		"http://127.0.0.1/":              http.StatusOK,
+		"http://[::1]:8080/":             http.StatusOK,
+	}
+
+	for url, expected := range tests {
+		res := serveRequest(t, handler, url)
+
+		if res.StatusCode != expected {
+			t.Errorf("%s: expected status %d, got %d", url, expected, res.StatusCode)
+		}
+	}
+}
+
+func Test_BlockUrls_ExpectHTTPS(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.ExpectHTTPS = true
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/login", map[string]string{"X-Forwarded-Proto": "http"}), http.StatusNotFound)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/login", map[string]string{"Forwarded": "for=2.56.20.0;proto=http"}), http.StatusNotFound)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/login"), http.StatusNotFound)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/login", map[string]string{"X-Forwarded-Proto": "https"}), http.StatusOK)
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/login", map[string]string{"Forwarded": `for=2.56.20.0;proto="HTTPS"`}), http.StatusOK)
+
+	req := httptest.NewRequest(http.MethodGet, "https://localhost/login", nil)
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+
+	assertStatusCode(t, recorder.Result(), http.StatusOK)
+}
+
+func Test_BlockUrls_ExpectHTTPSRedirect(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.ExpectHTTPS = true
+	cfg.ExpectHTTPSRedirect = true
+
+	handler := newHandler(t, cfg)
+
+	res := serveRequestWithHeaders(t, handler, "http://localhost:8080/login?next=/home", map[string]string{"X-Forwarded-Proto": "http"})
+
+	assertStatusCode(t, res, http.StatusPermanentRedirect)
+
+	if location := res.Header.Get("Location"); location != "https://localhost:8080/login?next=/home" {
+		t.Errorf("invalid redirect location: %q", location)
+	}
+
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/login", map[string]string{"X-Forwarded-Proto": "https"}), http.StatusOK)
+}
+
+func Test_BlockUrls_MaxTraversalDepth(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/a/../../../../../etc/passwd"), http.StatusOK)
+
+	cfg.MaxTraversalDepth = 2
+
+	handler = newHandler(t, cfg)
+
+	tests := map[string]int{
+		"http://localhost/docs/../index.html":                    http.StatusOK,
+		"http://localhost/a/b/../../index.html":                  http.StatusOK,
+		"http://localhost/a/../../../etc/passwd":                 http.StatusNotFound,
+		"http://localhost/%2e%2e/%2e%2e/%2E%2E/etc/passwd":       http.StatusNotFound,
+		"http://localhost/%252e%252e%252f%252e%252e%252f..%252f": http.StatusNotFound,
+		"http://localhost/..%5c..%5c..%5cwindows/win.ini":        http.StatusNotFound,
+		"http://localhost/release...notes/v1..2":                 http.StatusOK,
+	}
+
+	for url, expected := range tests {
+		assertStatusCode(t, serveRequest(t, handler, url), expected)
+	}
+}
+
+func Test_BlockUrls_BlockHighEntropyPath(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.BlockHighEntropyPath = true
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	tests := map[string]int{
+		"http://localhost/aZ8kQ2xLm9Pw4RtY7vBn3Hc6":                                                   http.StatusNotFound,
+		"http://localhost/static/Xq7Lp2Vz9Kd4Rw8Tn5Bm3Gh6.php":                                        http.StatusNotFound,
+		"http://localhost/blog/2024/my-first-post-about-go":                                           http.StatusOK,
+		"http://localhost/users/550e8400-e29b-41d4-a716-446655440000":                                 http.StatusOK,
+		"http://localhost/assets/9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08.js": http.StatusOK,
+		"http://localhost/aZ8kQ2xLm9Pw4":                                                              http.StatusOK,
+		"http://localhost/index.html":                                                                 http.StatusOK,
+	}
+
+	for url, expected := range tests {
+		assertStatusCode(t, serveRequest(t, handler, url), expected)
+	}
+
+	cfg.PathEntropyMinLength = 10
+
+	handler = newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/aZ8kQ2xLm9Pw4"), http.StatusOK)
+
+	cfg.PathEntropyThreshold = 3.5
+
+	handler = newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/aZ8kQ2xLm9Pw4"), http.StatusNotFound)
+}
+
+func Test_BlockUrls_BlockSmugglingIndicators(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.BlockSmugglingIndicators = true
+
+	handler := newHandler(t, cfg)
+
+	tests := []struct {
+		name             string
+		header           http.Header
+		transferEncoding []string
+		expected         int
+	}{
+		{"single content length", http.Header{"Content-Length": {"5"}}, nil, http.StatusOK},
+		{"duplicate content length", http.Header{"Content-Length": {"5", "7"}}, nil, http.StatusForbidden},
+		{"duplicate transfer encoding", http.Header{"Transfer-Encoding": {"chunked", "identity"}}, nil, http.StatusForbidden},
+		{"content length and transfer encoding header", http.Header{"Content-Length": {"5"}, "Transfer-Encoding": {"chunked"}}, nil, http.StatusForbidden},
+		{"content length and parsed transfer encoding", http.Header{"Content-Length": {"5"}}, []string{"chunked"}, http.StatusForbidden},
+		{"parsed transfer encoding only", http.Header{}, []string{"chunked"}, http.StatusOK},
+	}
+
+	for _, test := range tests {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, "http://localhost/upload", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header = test.header
+		req.TransferEncoding = test.transferEncoding
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+
+		if recorder.Code != test.expected {
+			t.Errorf("%s: unexpected status code %d, expected %d", test.name, recorder.Code, test.expected)
+		}
+	}
+}
+
+func Test_BlockUrls_BlockSNIHostMismatch(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.BlockSNIHostMismatch = true
+
+	handler := newHandler(t, cfg)
+
+	tests := []struct {
+		host       string
+		serverName string
+		tls        bool
+		expected   int
+	}{
+		{"shop.example.com", "shop.example.com", true, http.StatusOK},
+		{"Shop.Example.com:443", "shop.example.com", true, http.StatusOK},
+		{"admin.example.net", "shop.example.com", true, http.StatusForbidden},
+		{"admin.example.net", "", true, http.StatusOK},
+		{"admin.example.net", "", false, http.StatusOK},
+	}
+
+	for _, test := range tests {
+		req := httptest.NewRequest(http.MethodGet, "https://"+test.host+"/", nil)
+		if test.tls {
+			req.TLS = &tls.ConnectionState{ServerName: test.serverName}
+		} else {
+			req.TLS = nil
+		}
+
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, req)
+
+		if recorder.Code != test.expected {
+			t.Errorf("host %q, sni %q: unexpected status code %d, expected %d", test.host, test.serverName, recorder.Code, test.expected)
+		}
+	}
+}
+
+func Test_BlockUrls_BlockMixedCasePath(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.BlockMixedCasePath = true
+
+	handler := newHandler(t, cfg)
+
+	tests := map[string]int{
+		"http://localhost/wp-admin":               http.StatusOK,
+		"http://localhost/WpAdmin":                http.StatusOK,
+		"http://localhost/api/getUserById":        http.StatusOK,
+		"http://localhost/api/XMLHttpRequest":     http.StatusOK,
+		"http://localhost/aB/cD/eF/gH":            http.StatusOK,
+		"http://localhost/wPaDmIn":                http.StatusForbidden,
+		"http://localhost/static/xMl-RpC.php":     http.StatusForbidden,
+		"http://localhost/api/getUserProfileById": http.StatusOK,
+	}
+
+	for url, expected := range tests {
+		response := serveRequest(t, handler, url)
+		if response.StatusCode != expected {
+			t.Errorf("%s: unexpected status code %d, expected %d", url, response.StatusCode, expected)
+		}
+	}
+
+	cfg.MixedCaseMaxPercent = 50
+	handler = newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/api/getUserById"), http.StatusForbidden)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/WpAdmin"), http.StatusOK)
+
+	// catching PascalCase probes like WpAdmin takes a strict limit
+	cfg.MixedCaseMaxPercent = 40
+	handler = newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/WpAdmin"), http.StatusForbidden)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-admin"), http.StatusOK)
+}
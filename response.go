@@ -0,0 +1,168 @@
+package traefik_block_regex_urls
+
+import (
+	"cmp"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// defaultContentType is used for response bodies without a configured content type.
+const defaultContentType = "text/plain; charset=utf-8"
+
+// defaultDecoyContentType is used for decoy bodies without a configured content type.
+const defaultDecoyContentType = "text/html; charset=utf-8"
+
+// Actions taken on a blocked request.
+const (
+	// actionBlock writes the block status and body.
+	actionBlock = "block"
+	// actionDecoy pretends success with a 200 and the decoy body, so scanners move on.
+	actionDecoy = "decoy"
+	// actionRewrite passes the request on with its path replaced by the rewrite path, so the backend serves something benign.
+	actionRewrite = "rewrite"
+)
+
+// originalPathHeader carries the original path of a rewritten request, for logging downstream.
+const originalPathHeader = "X-Original-Path"
+
+// validateAction checks an action, empty falls back to the global action or block.
+// The rewrite action needs an absolute rewrite path.
+func validateAction(action string, rewritePath string) error {
+	switch action {
+	case "", actionBlock, actionDecoy:
+		return nil
+	case actionRewrite:
+		if !strings.HasPrefix(rewritePath, "/") {
+			return fmt.Errorf("invalid rewritePath %q for action %q, expected an absolute path", rewritePath, actionRewrite)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("invalid action %q, expected %q, %q or %q", action, actionBlock, actionDecoy, actionRewrite)
+	}
+}
+
+// respond writes the response for a blocked request, according to the action of the matched rule or the global one.
+func (blockUrls *traefik_block_regex_urls) respond(responseWriter http.ResponseWriter, request *http.Request, blockMatch *match) {
+	matchedRule := blockMatch.rule
+
+	action := blockUrls.action
+	if matchedRule != nil && matchedRule.action != "" {
+		action = matchedRule.action
+	}
+
+	if action == actionRewrite {
+		rewritePath := blockUrls.rewritePath
+		if matchedRule != nil && matchedRule.rewritePath != "" {
+			rewritePath = matchedRule.rewritePath
+		}
+
+		rewriteRequest(request, rewritePath)
+		blockUrls.next.ServeHTTP(responseWriter, request)
+		return
+	}
+
+	if action == actionDecoy {
+		body, contentType := blockUrls.decoyBody, blockUrls.decoyContentType
+		if contentType == "" {
+			contentType = defaultDecoyContentType
+		}
+
+		if matchedRule != nil && matchedRule.body != "" {
+			body, contentType = matchedRule.body, cmp.Or(matchedRule.contentType, contentType)
+		}
+
+		blockUrls.writeBody(responseWriter, request, http.StatusOK, contentType, body)
+		return
+	}
+
+	prefixResponse := blockUrls.prefixResponseFor(request.URL.Path)
+
+	statusCode := blockUrls.statusCode
+	if prefixResponse != nil && prefixResponse.statusCode != 0 {
+		statusCode = prefixResponse.statusCode
+	}
+
+	if matchedRule != nil && matchedRule.statusCode != 0 {
+		statusCode = matchedRule.statusCode
+	}
+
+	if matchedRule != nil && matchedRule.blockedBy != "" {
+		responseWriter.Header().Set("Link", blockedByLink(matchedRule.blockedBy))
+	}
+
+	if blockUrls.echoOnBlock {
+		blockUrls.writeEcho(responseWriter, request, blockMatch, statusCode)
+		return
+	}
+
+	body, contentType := blockUrls.blockBody, blockUrls.blockContentType
+	if blockUrls.bodyTemplate != "" {
+		body = blockUrls.renderBodyTemplate(blockUrls.bodyTemplate, contentType, request, blockMatch, statusCode)
+	}
+
+	if len(blockUrls.negotiatedBodies) > 0 {
+		if mediaType := negotiate(request.Header.Get("Accept"), blockUrls.negotiatedTypes); mediaType != "" {
+			contentType = mediaType + "; charset=utf-8"
+			body = blockUrls.renderBodyTemplate(blockUrls.negotiatedBodies[mediaType], contentType, request, blockMatch, statusCode)
+		}
+	}
+
+	if prefixResponse != nil && prefixResponse.body != "" {
+		body, contentType = prefixResponse.body, cmp.Or(prefixResponse.contentType, blockUrls.blockContentType)
+	}
+
+	if matchedRule != nil && matchedRule.body != "" {
+		body, contentType = matchedRule.body, cmp.Or(matchedRule.contentType, contentType)
+	}
+
+	if body == "" && blockUrls.useHTTPError && bodyAllowed(statusCode) {
+		message := blockUrls.httpErrorMessage
+		if message == "" {
+			message = http.StatusText(statusCode)
+		}
+
+		http.Error(responseWriter, message, statusCode)
+		return
+	}
+
+	blockUrls.writeBody(responseWriter, request, statusCode, contentType, body)
+}
+
+// rewriteRequest replaces the path of the request, keeping the query, and records the original path in a header.
+func rewriteRequest(request *http.Request, rewritePath string) {
+	request.Header.Set(originalPathHeader, request.URL.Path)
+
+	request.URL.Path = rewritePath
+	request.URL.RawPath = ""
+	request.RequestURI = request.URL.RequestURI()
+}
+
+// writeResponse writes the status code and, unless the status forbids one, the body.
+func writeResponse(responseWriter http.ResponseWriter, statusCode int, contentType string, body string) {
+	header := responseWriter.Header()
+
+	if body == "" || !bodyAllowed(statusCode) {
+		// make sure nothing announces a body which is not sent
+		header.Del("Content-Length")
+		header.Del("Content-Type")
+		responseWriter.WriteHeader(statusCode)
+		return
+	}
+
+	if contentType == "" {
+		contentType = defaultContentType
+	}
+
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Length", strconv.Itoa(len(body)))
+	responseWriter.WriteHeader(statusCode)
+	_, _ = responseWriter.Write([]byte(body))
+}
+
+// bodyAllowed reports whether a response with the status code may carry a body.
+func bodyAllowed(statusCode int) bool {
+	return statusCode >= http.StatusOK && statusCode != http.StatusNoContent && statusCode != http.StatusNotModified
+}
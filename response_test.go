@@ -0,0 +1,423 @@
+package traefik_block_regex_urls_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+func Test_BlockUrls_Rules_WriteRuleBodies(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Rules = []BlockUrls.Rule{
+		{Regex: "(.*)/admin", Body: "<h1>Nothing to see here</h1>", ContentType: "text/html; charset=utf-8"},
+		{Regex: "(.*)/api/internal", Body: `{"error":"forbidden"}`, ContentType: "application/json"},
+		{Regex: "(.*)/scanner", StatusCode: 444},
+	}
+	cfg.BlockBody = "blocked"
+
+	handler := newHandler(t, cfg)
+
+	response := serveRequest(t, handler, "http://localhost/admin")
+	assertStatusCode(t, response, http.StatusForbidden)
+	assertBody(t, response, "text/html; charset=utf-8", "<h1>Nothing to see here</h1>")
+
+	response = serveRequest(t, handler, "http://localhost/api/internal")
+	assertStatusCode(t, response, http.StatusForbidden)
+	assertBody(t, response, "application/json", `{"error":"forbidden"}`)
+
+	response = serveRequest(t, handler, "http://localhost/scanner")
+	assertStatusCode(t, response, 444)
+	assertBody(t, response, "text/plain; charset=utf-8", "blocked")
+}
+
+func Test_BlockUrls_BlockBody_IsNotWrittenFor204(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/drain"}
+	cfg.BlockBody = "blocked"
+	cfg.StatusCode = http.StatusNoContent
+
+	handler := newHandler(t, cfg)
+
+	response := serveRequest(t, handler, "http://localhost/drain")
+	assertStatusCode(t, response, http.StatusNoContent)
+	assertEmptyBody(t, response)
+}
+
+func assertBody(t *testing.T, res *http.Response, contentType string, expected string) {
+	t.Helper()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(body) != expected {
+		t.Errorf("invalid body: %q <> %q", expected, body)
+	}
+
+	if received := res.Header.Get("Content-Type"); received != contentType {
+		t.Errorf("invalid content type: %q <> %q", contentType, received)
+	}
+}
+
+func Test_BlockUrls_Rules_SilencedRuleBlocksWithoutLogging(t *testing.T) {
+	var output bytes.Buffer
+
+	log.SetOutput(&output)
+	defer log.SetOutput(os.Stderr)
+
+	silent := false
+
+	cfg := BlockUrls.CreateConfig()
+	cfg.Rules = []BlockUrls.Rule{
+		{Regex: "(.*)/noisy-scanner", Log: &silent},
+		{Regex: "(.*)/precise-probe"},
+	}
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/noisy-scanner"), http.StatusForbidden)
+
+	if output.Len() != 0 {
+		t.Errorf("expected no log line for a silenced rule, got %q", output.String())
+	}
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/precise-probe"), http.StatusForbidden)
+
+	if !strings.Contains(output.String(), "URL is blocked (rule match): (localhost/precise-probe)") {
+		t.Errorf("expected a log line for a logged rule, got %q", output.String())
+	}
+}
+
+func Test_BlockUrls_DecoyAction_Returns200WithDecoyBody(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login.php"}
+	cfg.Rules = []BlockUrls.Rule{
+		{Regex: "(.*)/phpmyadmin", Action: "decoy", Body: "<h1>phpMyAdmin</h1>"},
+		{Regex: "(.*)/admin"},
+	}
+	cfg.Action = "decoy"
+	cfg.DecoyBody = "<html><body>Welcome</body></html>"
+
+	handler := newHandler(t, cfg)
+
+	response := serveRequest(t, handler, "http://localhost/wp-login.php")
+	assertStatusCode(t, response, http.StatusOK)
+	if response.Header.Get("Content-Length") != "33" {
+		t.Errorf("unexpected Content-Length %q", response.Header.Get("Content-Length"))
+	}
+	assertBody(t, response, "text/html; charset=utf-8", "<html><body>Welcome</body></html>")
+
+	response = serveRequest(t, handler, "http://localhost/phpmyadmin")
+	assertStatusCode(t, response, http.StatusOK)
+	assertBody(t, response, "text/html; charset=utf-8", "<h1>phpMyAdmin</h1>")
+}
+
+func Test_BlockUrls_DecoyAction_RuleBodyInheritsDecoyContentType(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Rules = []BlockUrls.Rule{
+		{Regex: "(.*)/api/users", Action: "decoy", Body: `{"users":[]}`},
+		{Regex: "(.*)/api/export", Action: "decoy", Body: "id,name", ContentType: "text/csv"},
+	}
+	cfg.DecoyContentType = "application/json"
+
+	handler := newHandler(t, cfg)
+
+	response := serveRequest(t, handler, "http://localhost/api/users")
+	assertStatusCode(t, response, http.StatusOK)
+	assertBody(t, response, "application/json", `{"users":[]}`)
+
+	response = serveRequest(t, handler, "http://localhost/api/export")
+	assertStatusCode(t, response, http.StatusOK)
+	assertBody(t, response, "text/csv", "id,name")
+}
+
+func Test_BlockUrls_RuleAction_OverridesGlobalAction(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Rules = []BlockUrls.Rule{
+		{Regex: "(.*)/phpmyadmin", Action: "decoy"},
+		{Regex: "(.*)/admin"},
+	}
+	cfg.DecoyBody = "ok"
+
+	handler := newHandler(t, cfg)
+
+	response := serveRequest(t, handler, "http://localhost/phpmyadmin")
+	assertStatusCode(t, response, http.StatusOK)
+	assertBody(t, response, "text/html; charset=utf-8", "ok")
+
+	response = serveRequest(t, handler, "http://localhost/admin")
+	assertStatusCode(t, response, http.StatusForbidden)
+}
+
+func Test_BlockUrls_InvalidAction_Fails(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Action = "teapot"
+
+	_, err := BlockUrls.New(context.Background(), nil, cfg, "test")
+	if err == nil {
+		t.Fatal("expected an error for an invalid action")
+	}
+}
+
+func Test_BlockUrls_RewriteAction_PassesRewrittenPathDownstream(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login.php"}
+	cfg.Rules = []BlockUrls.Rule{
+		{Regex: "(.*)/.env", Action: "rewrite", RewritePath: "/static/empty.txt"},
+	}
+	cfg.Action = "rewrite"
+	cfg.RewritePath = "/static/empty.html"
+
+	var path, requestURI, originalPath string
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		path = req.URL.Path
+		requestURI = req.RequestURI
+		originalPath = req.Header.Get("X-Original-Path")
+	})
+
+	handler, err := BlockUrls.New(context.Background(), next, cfg, "BlockUrls")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response := serveRequest(t, handler, "http://localhost/wp-login.php?redirect=1")
+	assertStatusCode(t, response, http.StatusOK)
+
+	if path != "/static/empty.html" || requestURI != "/static/empty.html?redirect=1" || originalPath != "/wp-login.php" {
+		t.Errorf("unexpected rewrite: path %q, request uri %q, original path %q", path, requestURI, originalPath)
+	}
+
+	serveRequest(t, handler, "http://localhost/app/.env")
+
+	if path != "/static/empty.txt" || originalPath != "/app/.env" {
+		t.Errorf("unexpected rule rewrite: path %q, original path %q", path, originalPath)
+	}
+}
+
+func Test_BlockUrls_RewriteAction_RequiresRewritePath(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Rules = []BlockUrls.Rule{{Regex: "(.*)/.env", Action: "rewrite"}}
+
+	_, err := BlockUrls.New(context.Background(), nil, cfg, "BlockUrls")
+	if err == nil {
+		t.Fatal("expected an error for a rewrite action without rewritePath")
+	}
+}
+
+func Test_BlockUrls_UseHTTPError(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login"}
+
+	handler := newHandler(t, cfg)
+
+	res := serveRequest(t, handler, "http://localhost/wp-login")
+
+	assertStatusCode(t, res, http.StatusForbidden)
+	assertEmptyBody(t, res)
+
+	cfg.UseHTTPError = true
+
+	handler = newHandler(t, cfg)
+
+	res = serveRequest(t, handler, "http://localhost/wp-login")
+
+	assertStatusCode(t, res, http.StatusForbidden)
+	assertBody(t, res, "text/plain; charset=utf-8", "Forbidden\n")
+
+	if nosniff := res.Header.Get("X-Content-Type-Options"); nosniff != "nosniff" {
+		t.Errorf("expected the http.Error nosniff header, got %q", nosniff)
+	}
+
+	cfg.HTTPErrorMessage = "Access denied"
+
+	handler = newHandler(t, cfg)
+
+	assertBody(t, serveRequest(t, handler, "http://localhost/wp-login"), "text/plain; charset=utf-8", "Access denied\n")
+
+	// a configured body still wins
+	cfg.BlockBody = "blocked"
+
+	handler = newHandler(t, cfg)
+
+	assertBody(t, serveRequest(t, handler, "http://localhost/wp-login"), "text/plain; charset=utf-8", "blocked")
+}
+
+func Test_BlockUrls_EchoOnBlock(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login"}
+	cfg.BlockBody = "blocked"
+	cfg.EchoOnBlock = true
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	handler := newHandler(t, cfg)
+
+	if !strings.Contains(buf.String(), "Warning: echoOnBlock") {
+		t.Errorf("expected a start up warning, got %q", buf.String())
+	}
+
+	res := serveRequestWithHeaders(t, handler, "http://localhost/wp-login.php?uid=1", map[string]string{"X-Forwarded-For": "203.0.113.7"})
+
+	assertStatusCode(t, res, http.StatusForbidden)
+
+	if contentType := res.Header.Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("expected a JSON echo, got content type %q", contentType)
+	}
+
+	var echo map[string]any
+	if err := json.NewDecoder(res.Body).Decode(&echo); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]any{
+		"method":  "GET",
+		"host":    "localhost",
+		"path":    "/wp-login.php",
+		"query":   "uid=1",
+		"ip":      "203.0.113.7",
+		"reason":  "regex match",
+		"pattern": "(.*)/wp-login",
+		"status":  float64(http.StatusForbidden),
+	}
+
+	for field, value := range expected {
+		if echo[field] != value {
+			t.Errorf("expected echoed %s %v, got %v", field, value, echo[field])
+		}
+	}
+}
+
+func Test_BlockUrls_GzipBlockBody(t *testing.T) {
+	blockPage := "<html><body>" + strings.Repeat("<p>Access denied.</p>", 100) + "</body></html>"
+
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login"}
+	cfg.BlockBody = blockPage
+	cfg.BlockContentType = "text/html; charset=utf-8"
+	cfg.GzipBlockBody = true
+
+	handler := newHandler(t, cfg)
+
+	// gzip client
+	res := serveRequestWithHeaders(t, handler, "http://localhost/wp-login", map[string]string{"Accept-Encoding": "br, gzip;q=0.8"})
+
+	assertStatusCode(t, res, http.StatusForbidden)
+
+	if encoding := res.Header.Get("Content-Encoding"); encoding != "gzip" {
+		t.Fatalf("expected a gzip body, got content encoding %q", encoding)
+	}
+
+	if contentType := res.Header.Get("Content-Type"); contentType != "text/html; charset=utf-8" {
+		t.Errorf("expected the block content type, got %q", contentType)
+	}
+
+	reader, err := gzip.NewReader(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if string(body) != blockPage {
+		t.Errorf("expected the decompressed block page, got %q", body)
+	}
+
+	// non-gzip clients
+	for _, acceptEncoding := range []string{"", "br", "gzip;q=0"} {
+		res = serveRequestWithHeaders(t, handler, "http://localhost/wp-login", map[string]string{"Accept-Encoding": acceptEncoding})
+
+		if encoding := res.Header.Get("Content-Encoding"); encoding != "" {
+			t.Errorf("accept encoding %q: expected no content encoding, got %q", acceptEncoding, encoding)
+		}
+
+		assertBody(t, res, "text/html; charset=utf-8", blockPage)
+	}
+
+	// small bodies are sent as is
+	cfg.BlockBody = "blocked"
+
+	handler = newHandler(t, cfg)
+
+	res = serveRequestWithHeaders(t, handler, "http://localhost/wp-login", map[string]string{"Accept-Encoding": "gzip"})
+
+	if encoding := res.Header.Get("Content-Encoding"); encoding != "" {
+		t.Errorf("expected no content encoding for a small body, got %q", encoding)
+	}
+
+	assertBody(t, res, "text/html; charset=utf-8", "blocked")
+}
+
+func Test_BlockUrls_PrefixResponses(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/(admin|debug)"}
+	cfg.BlockBody = "<h1>Forbidden</h1>"
+	cfg.BlockContentType = "text/html; charset=utf-8"
+	cfg.PrefixResponses = []BlockUrls.PrefixResponse{
+		{Prefix: "/api/", ContentType: "application/json", Body: `{"error":"forbidden"}`},
+		{Prefix: "/api/internal/", StatusCode: http.StatusNotFound},
+	}
+
+	handler := newHandler(t, cfg)
+
+	// API path
+	res := serveRequest(t, handler, "http://localhost/api/admin")
+	assertStatusCode(t, res, http.StatusForbidden)
+	assertBody(t, res, "application/json", `{"error":"forbidden"}`)
+
+	// page path, the global block response
+	res = serveRequest(t, handler, "http://localhost/admin")
+	assertStatusCode(t, res, http.StatusForbidden)
+	assertBody(t, res, "text/html; charset=utf-8", "<h1>Forbidden</h1>")
+
+	// the longest prefix wins, unset fields fall back to the global ones
+	res = serveRequest(t, handler, "http://localhost/api/internal/debug")
+	assertStatusCode(t, res, http.StatusNotFound)
+	assertBody(t, res, "text/html; charset=utf-8", "<h1>Forbidden</h1>")
+
+	cfg.PrefixResponses = []BlockUrls.PrefixResponse{{Prefix: "api/"}}
+
+	if _, err := BlockUrls.New(context.Background(), nil, cfg, "BlockUrls"); err == nil {
+		t.Fatal("expected an error for a relative prefix")
+	}
+}
+
+func Test_BlockUrls_PrefixResponses_InheritContentType(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/admin"}
+	cfg.BlockContentType = "application/json"
+	cfg.PrefixResponses = []BlockUrls.PrefixResponse{{Prefix: "/api/", Body: `{"error":"forbidden"}`}}
+
+	handler := newHandler(t, cfg)
+
+	res := serveRequest(t, handler, "http://localhost/api/admin")
+	assertStatusCode(t, res, http.StatusForbidden)
+	assertBody(t, res, "application/json", `{"error":"forbidden"}`)
+}
+
+func Test_BlockUrls_Rules_InheritContentType(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Rules = []BlockUrls.Rule{{Regex: "(.*)/admin", Body: `{"error":"forbidden"}`}}
+	cfg.BlockContentType = "application/json"
+
+	handler := newHandler(t, cfg)
+
+	res := serveRequest(t, handler, "http://localhost/admin")
+	assertStatusCode(t, res, http.StatusForbidden)
+	assertBody(t, res, "application/json", `{"error":"forbidden"}`)
+}
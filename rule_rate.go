@@ -0,0 +1,62 @@
+package traefik_block_regex_urls
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// defaultRuleRateWindow is the window of a rule rate limit without an explicit one.
+const defaultRuleRateWindow = time.Minute
+
+// ruleRate counts the matches of a rule across all clients in a fixed window.
+type ruleRate struct {
+	mu          sync.Mutex
+	limit       int
+	window      time.Duration
+	windowStart time.Time
+	count       int
+}
+
+// newRuleRate returns nil if the rule has no rate limit.
+func newRuleRate(configRule Rule) (*ruleRate, error) {
+	if configRule.RateLimit <= 0 {
+		return nil, nil
+	}
+
+	window := defaultRuleRateWindow
+	if configRule.RateWindow != "" {
+		parsedWindow, parseError := time.ParseDuration(configRule.RateWindow)
+		if parseError != nil || parsedWindow <= 0 {
+			return nil, fmt.Errorf("invalid rateWindow %q", configRule.RateWindow)
+		}
+
+		window = parsedWindow
+	}
+
+	return &ruleRate{limit: configRule.RateLimit, window: window}, nil
+}
+
+// exceeded reports whether a match would make more than limit matches in the current window,
+// and counts it if record is set.
+func (rate *ruleRate) exceeded(now time.Time, record bool) bool {
+	rate.mu.Lock()
+	defer rate.mu.Unlock()
+
+	expired := now.Sub(rate.windowStart) >= rate.window
+
+	count := rate.count + 1
+	if expired {
+		count = 1
+	}
+
+	if record {
+		if expired {
+			rate.windowStart = now
+		}
+
+		rate.count = count
+	}
+
+	return count > rate.limit
+}
@@ -0,0 +1,53 @@
+package traefik_block_regex_urls_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+func Test_BlockUrls_RuleRateLimit(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.MatchScope = "path"
+	cfg.Rules = []BlockUrls.Rule{
+		{Regex: "^/api/password-reset", StatusCode: 429, RateLimit: 3, RateWindow: "1m"},
+		{Regex: "^/wp-login", StatusCode: 404},
+	}
+
+	clock := newFakeClock()
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	handler, err := BlockUrls.NewWithOptions(context.Background(), next, cfg, "BlockUrls", BlockUrls.WithClock(clock.Now))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		assertStatusCode(t, serveRequest(t, handler, "http://localhost/api/password-reset"), http.StatusOK)
+		clock.Advance(10 * time.Second)
+	}
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/api/password-reset"), http.StatusTooManyRequests)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/api/password-reset"), http.StatusTooManyRequests)
+
+	// rules without a rate limit always block
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-login"), http.StatusNotFound)
+
+	clock.Advance(time.Minute)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/api/password-reset"), http.StatusOK)
+}
+
+func Test_BlockUrls_RuleRateLimit_InvalidWindow(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Rules = []BlockUrls.Rule{{Regex: "^/api", RateLimit: 3, RateWindow: "often"}}
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	if _, err := BlockUrls.New(context.Background(), next, cfg, "BlockUrls"); err == nil {
+		t.Fatal("expected an error for an invalid rateWindow")
+	}
+}
@@ -0,0 +1,83 @@
+package traefik_block_regex_urls
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// RuleSet is an independent group of rules matched against its own scope of the url.
+type RuleSet struct {
+	MatchScope string   `yaml:"matchScope,omitempty"`
+	Regex      []string `yaml:"regex,omitempty"`
+	Strings    []string `yaml:"strings,omitempty"`
+	StatusCode int      `yaml:"statusCode,omitempty"`
+}
+
+// ruleSet is a compiled RuleSet.
+type ruleSet struct {
+	matchScope   string
+	regexps      []*regexp.Regexp
+	matchStrings []string
+	response     *rule
+}
+
+// parseMatchScope validates the scope, empty defaults to the full url.
+func parseMatchScope(scope string) (string, error) {
+	switch scope {
+	case "":
+		return matchScopeFull, nil
+	case matchScopeFull, matchScopePath, matchScopePathQuery, matchScopeHost, matchScopeHostPath:
+		return scope, nil
+	default:
+		return "", fmt.Errorf("invalid matchScope %q, expected %q, %q, %q, %q or %q", scope, matchScopeFull, matchScopePath, matchScopePathQuery, matchScopeHost, matchScopeHostPath)
+	}
+}
+
+// compileRuleSets compiles the rule sets.
+func compileRuleSets(ruleSets []RuleSet) ([]*ruleSet, error) {
+	compiledRuleSets := make([]*ruleSet, len(ruleSets))
+
+	for index, configRuleSet := range ruleSets {
+		matchScope, scopeError := parseMatchScope(configRuleSet.MatchScope)
+		if scopeError != nil {
+			return nil, fmt.Errorf("error in rule set %d: %w", index, scopeError)
+		}
+
+		regexps, compileError := compileRegexList(configRuleSet.Regex)
+		if compileError != nil {
+			return nil, fmt.Errorf("error in rule set %d: %w", index, compileError)
+		}
+
+		compiledRuleSets[index] = &ruleSet{
+			matchScope:   matchScope,
+			regexps:      regexps,
+			matchStrings: configRuleSet.Strings,
+			response:     &rule{statusCode: configRuleSet.StatusCode},
+		}
+	}
+
+	return compiledRuleSets, nil
+}
+
+// matchRuleSets runs the request against every rule set, each on its own scoped target.
+func (blockUrls *traefik_block_regex_urls) matchRuleSets(request *http.Request) *match {
+	for _, set := range blockUrls.ruleSets {
+		target := blockUrls.scopedTarget(request, set.matchScope)
+
+		for _, matchString := range set.matchStrings {
+			if strings.Contains(target, matchString) {
+				return &match{reason: "rule set string match", url: fullURL(request), pattern: matchString, rule: set.response}
+			}
+		}
+
+		for _, regex := range set.regexps {
+			if regex.MatchString(target) {
+				return &match{reason: "rule set regex match", url: fullURL(request), pattern: regex.String(), rule: set.response}
+			}
+		}
+	}
+
+	return nil
+}
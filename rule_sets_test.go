@@ -0,0 +1,45 @@
+package traefik_block_regex_urls_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+func Test_BlockUrls_RuleSets_CombineScopes(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{`^localhost/legacy`}
+	cfg.RuleSets = []BlockUrls.RuleSet{
+		{MatchScope: "host", Regex: []string{`^staging\.`}, StatusCode: http.StatusUnauthorized},
+		{MatchScope: "path", Regex: []string{`^/wp-`}, Strings: []string{"/.git/"}, StatusCode: http.StatusNotFound},
+	}
+
+	handler := newHandler(t, cfg)
+
+	tests := map[string]int{
+		"http://staging.example.com/":           http.StatusUnauthorized,
+		"http://www.example.com/wp-login.php":   http.StatusNotFound,
+		"http://www.example.com/app/.git/HEAD":  http.StatusNotFound,
+		"http://staging.example.com/wp-login":   http.StatusUnauthorized,
+		"http://localhost/legacy":               http.StatusForbidden,
+		"http://www.example.com/index.html":     http.StatusOK,
+		"http://www.example.com/?next=/wp-json": http.StatusOK,
+	}
+
+	for url, expected := range tests {
+		assertStatusCode(t, serveRequest(t, handler, url), expected)
+	}
+}
+
+func Test_BlockUrls_RuleSets_ReturnsError_IfInvalidScope(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.RuleSets = []BlockUrls.RuleSet{{MatchScope: "cookie"}}
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	if _, err := BlockUrls.New(context.Background(), next, cfg, "BlockUrls"); err == nil {
+		t.Fatal("expected an error for an invalid rule set scope")
+	}
+}
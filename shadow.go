@@ -0,0 +1,57 @@
+package traefik_block_regex_urls
+
+import (
+	"net/http"
+	"regexp"
+	"sync"
+)
+
+// shadowRules are candidate rules which are only logged and counted, they never affect the response.
+type shadowRules struct {
+	regexps []*regexp.Regexp
+
+	mu      sync.Mutex
+	matches map[string]uint64
+}
+
+func newShadowRules(regexps []*regexp.Regexp) *shadowRules {
+	return &shadowRules{
+		regexps: regexps,
+		matches: map[string]uint64{},
+	}
+}
+
+// evaluateShadow logs and counts every shadow rule matching the request target.
+func (blockUrls *traefik_block_regex_urls) evaluateShadow(request *http.Request) {
+	target := blockUrls.matchTarget(request)
+
+	for _, regex := range blockUrls.shadowRules.regexps {
+		if !regex.MatchString(target) {
+			continue
+		}
+
+		blockUrls.logger.Printf("URL would be blocked (shadow block, %s): (%s) middleware=%s", regex.String(), fullURL(request), blockUrls.name)
+
+		blockUrls.shadowRules.mu.Lock()
+		blockUrls.shadowRules.matches[regex.String()]++
+		blockUrls.shadowRules.mu.Unlock()
+	}
+}
+
+// ShadowMatches returns how often each shadow regex matched, by pattern.
+func (blockUrls *traefik_block_regex_urls) ShadowMatches() map[string]uint64 {
+	matches := map[string]uint64{}
+
+	if blockUrls.shadowRules == nil {
+		return matches
+	}
+
+	blockUrls.shadowRules.mu.Lock()
+	defer blockUrls.shadowRules.mu.Unlock()
+
+	for pattern, count := range blockUrls.shadowRules.matches {
+		matches[pattern] = count
+	}
+
+	return matches
+}
@@ -0,0 +1,36 @@
+package traefik_block_regex_urls_test
+
+import (
+	"net/http"
+	"reflect"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+type shadowReporter interface {
+	ShadowMatches() map[string]uint64
+}
+
+func Test_BlockUrls_ShadowRegex_RecordsWithoutBlocking(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login"}
+	cfg.ShadowRegex = []string{"(.*)/api/v1/(.*)", "(.*)/wp-(.*)"}
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/api/v1/users"), http.StatusOK)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/api/v1/orders"), http.StatusOK)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-login"), http.StatusNotFound)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/index.html"), http.StatusOK)
+
+	expected := map[string]uint64{
+		"(.*)/api/v1/(.*)": 2,
+		"(.*)/wp-(.*)":     1,
+	}
+
+	if matches := handler.(shadowReporter).ShadowMatches(); !reflect.DeepEqual(matches, expected) {
+		t.Errorf("unexpected shadow matches: %v", matches)
+	}
+}
@@ -0,0 +1,15 @@
+package traefik_block_regex_urls
+
+// inStartupGrace reports whether the startup grace is still running, when every request is passed through.
+// The first call after the grace logs that enforcement begins.
+func (blockUrls *traefik_block_regex_urls) inStartupGrace() bool {
+	if blockUrls.now().Sub(blockUrls.startedAt) < blockUrls.startupGrace {
+		return true
+	}
+
+	blockUrls.enforcementStarted.Do(func() {
+		blockUrls.logger.Printf("Startup grace of %s elapsed, enforcing the rules: middleware=%s", blockUrls.startupGrace, blockUrls.name)
+	})
+
+	return false
+}
@@ -0,0 +1,140 @@
+package traefik_block_regex_urls
+
+import (
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"sort"
+	"time"
+)
+
+// state is the runtime state of a plugin instance as exported by ExportState. Sections of features
+// which are not enabled are left out.
+type state struct {
+	// Middleware is the name of the exporting instance, informative only.
+	Middleware    string               `json:"middleware"`
+	Decisions     map[string]uint64    `json:"decisions"`
+	Patterns      map[string]uint64    `json:"patterns"`
+	ShadowMatches map[string]uint64    `json:"shadowMatches,omitempty"`
+	TopBlocked    map[string]uint64    `json:"topBlocked,omitempty"`
+	TopBlockedIPs map[string]uint64    `json:"topBlockedIPs,omitempty"`
+	Grace         map[string]time.Time `json:"grace,omitempty"`
+}
+
+// ExportState returns the runtime state of the instance as JSON: the decision and pattern counters,
+// the shadow matches, the top blocked urls and ips, and the client ips which used their first request grace.
+// Embedders can persist it and restore it with ImportState after a restart.
+func (blockUrls *traefik_block_regex_urls) ExportState() ([]byte, error) {
+	exported := state{Middleware: blockUrls.name}
+
+	blockUrls.counters.mu.Lock()
+	exported.Decisions = maps.Clone(blockUrls.counters.outcomes)
+	exported.Patterns = maps.Clone(blockUrls.counters.patterns)
+	blockUrls.counters.mu.Unlock()
+
+	if blockUrls.shadowRules != nil {
+		exported.ShadowMatches = blockUrls.ShadowMatches()
+	}
+
+	if blockUrls.topBlocked != nil {
+		exported.TopBlocked = blockUrls.topBlocked.snapshot()
+	}
+
+	if blockUrls.topBlockedIPs != nil {
+		exported.TopBlockedIPs = blockUrls.topBlockedIPs.snapshot()
+	}
+
+	if blockUrls.graceTracker != nil {
+		blockUrls.graceTracker.mu.Lock()
+		exported.Grace = maps.Clone(blockUrls.graceTracker.seen)
+		blockUrls.graceTracker.mu.Unlock()
+	}
+
+	return json.Marshal(exported)
+}
+
+// ImportState replaces the runtime state of the instance with one returned by ExportState.
+// Sections of features not enabled on the instance are ignored, as are expired grace entries,
+// and the top counters keep their most counted entries up to topBlockedMaxEntries.
+func (blockUrls *traefik_block_regex_urls) ImportState(data []byte) error {
+	var imported state
+	if unmarshalError := json.Unmarshal(data, &imported); unmarshalError != nil {
+		return fmt.Errorf("invalid state: %w", unmarshalError)
+	}
+
+	blockUrls.counters.mu.Lock()
+	blockUrls.counters.outcomes = cloneCounts(imported.Decisions)
+	blockUrls.counters.patterns = cloneCounts(imported.Patterns)
+	blockUrls.counters.mu.Unlock()
+
+	if blockUrls.shadowRules != nil {
+		blockUrls.shadowRules.mu.Lock()
+		blockUrls.shadowRules.matches = cloneCounts(imported.ShadowMatches)
+		blockUrls.shadowRules.mu.Unlock()
+	}
+
+	if blockUrls.topBlocked != nil {
+		blockUrls.topBlocked.restore(imported.TopBlocked)
+	}
+
+	if blockUrls.topBlockedIPs != nil {
+		blockUrls.topBlockedIPs.restore(imported.TopBlockedIPs)
+	}
+
+	if blockUrls.graceTracker != nil {
+		blockUrls.graceTracker.restore(imported.Grace, blockUrls.now())
+	}
+
+	blockUrls.logger.Printf("Imported state of %q: middleware=%s", imported.Middleware, blockUrls.name)
+
+	return nil
+}
+
+// cloneCounts returns a copy of the counts, never nil so counting can go on.
+func cloneCounts(counts map[string]uint64) map[string]uint64 {
+	cloned := make(map[string]uint64, len(counts))
+	maps.Copy(cloned, counts)
+
+	return cloned
+}
+
+// snapshot returns a copy of the counts.
+func (counter *topCounter) snapshot() map[string]uint64 {
+	return counter.counts()
+}
+
+// restore replaces the counts, keeping the most counted keys if there are more than maxEntries.
+func (counter *topCounter) restore(counts map[string]uint64) {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+
+		return keys[i] < keys[j]
+	})
+
+	if len(keys) > counter.maxEntries {
+		keys = keys[:counter.maxEntries]
+	}
+
+	entries := make(map[string]*topEntry, len(keys))
+	byCount := make(topHeap, len(keys))
+
+	for index, key := range keys {
+		entries[key] = &topEntry{key: key, count: counts[key], index: index}
+		byCount[index] = entries[key]
+	}
+
+	heap.Init(&byCount)
+
+	counter.mu.Lock()
+	counter.entries = entries
+	counter.byCount = byCount
+	counter.mu.Unlock()
+}
@@ -0,0 +1,128 @@
+package traefik_block_regex_urls_test
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"reflect"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+type stateHandler interface {
+	http.Handler
+	statsReporter
+	topBlockedReporter
+	ExportState() ([]byte, error)
+	ImportState(data []byte) error
+}
+
+func newStateHandler(t *testing.T) stateHandler {
+	t.Helper()
+
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp(.*)", "(.*)/.env"}
+	cfg.ShadowRegex = []string{"(.*)/admin"}
+	cfg.TrackTopBlocked = true
+	cfg.TrackTopBlockedIPs = true
+	cfg.GraceFirstRequest = true
+
+	return newHandler(t, cfg).(stateHandler)
+}
+
+func Test_BlockUrls_State_RoundTrip(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	client := map[string]string{"X-Forwarded-For": "2.56.20.1"}
+	before := newStateHandler(t)
+
+	// the first one is let through by the first request grace
+	serveRequestWithHeaders(t, before, "http://localhost/wp-login.php", client)
+	serveRequestWithHeaders(t, before, "http://localhost/wp-login.php", client)
+	serveRequestWithHeaders(t, before, "http://localhost/wp-admin", client)
+	serveRequestWithHeaders(t, before, "http://localhost/.env", client)
+
+	exported, err := before.ExportState()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	after := newStateHandler(t)
+	if err := after.ImportState(exported); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(after.Stats(), before.Stats()) {
+		t.Errorf("expected stats %+v, got %+v", before.Stats(), after.Stats())
+	}
+
+	if !reflect.DeepEqual(after.PatternStats(), before.PatternStats()) {
+		t.Errorf("expected pattern stats %+v, got %+v", before.PatternStats(), after.PatternStats())
+	}
+
+	if !reflect.DeepEqual(after.TopBlocked(-1), before.TopBlocked(-1)) {
+		t.Errorf("expected top blocked %+v, got %+v", before.TopBlocked(-1), after.TopBlocked(-1))
+	}
+
+	reexported, err := after.ExportState()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(reexported, exported) {
+		t.Errorf("expected the imported state to export as %s, got %s", exported, reexported)
+	}
+
+	// the client used its grace before the restart
+	assertStatusCode(t, serveRequestWithHeaders(t, after, "http://localhost/wp-login.php", client), http.StatusForbidden)
+
+	if stats := after.Stats(); stats.Blocked != 4 || stats.Allowed != 1 {
+		t.Errorf("expected the counters to go on from the imported state, got %+v", stats)
+	}
+}
+
+func Test_BlockUrls_State_IgnoresDisabledFeatures(t *testing.T) {
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	client := map[string]string{"X-Forwarded-For": "2.56.20.1"}
+	before := newStateHandler(t)
+	serveRequestWithHeaders(t, before, "http://localhost/.env", client)
+	serveRequestWithHeaders(t, before, "http://localhost/.env", client)
+
+	exported, err := before.ExportState()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/.env"}
+
+	after := newHandler(t, cfg).(stateHandler)
+	if err := after.ImportState(exported); err != nil {
+		t.Fatal(err)
+	}
+
+	if stats := after.Stats(); stats.Blocked != 1 || stats.Allowed != 1 {
+		t.Errorf("expected the imported counters, got %+v", stats)
+	}
+
+	if topBlocked := after.TopBlocked(-1); topBlocked != nil {
+		t.Errorf("expected no top blocked without trackTopBlocked, got %+v", topBlocked)
+	}
+
+	// without graceFirstRequest there is no grace to restore
+	assertStatusCode(t, serveRequestWithHeaders(t, after, "http://localhost/.env", client), http.StatusForbidden)
+}
+
+func Test_BlockUrls_State_InvalidState(t *testing.T) {
+	handler := newStateHandler(t)
+
+	if err := handler.ImportState([]byte("not json")); err == nil {
+		t.Error("expected an error for an invalid state")
+	}
+}
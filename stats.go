@@ -0,0 +1,90 @@
+package traefik_block_regex_urls
+
+import (
+	"net/http"
+	"sync"
+)
+
+// Stats are the decision counters of a plugin instance, named after it, so embedders running several instances
+// can tell them apart.
+type Stats struct {
+	Middleware string `json:"middleware"`
+	Blocked    uint64 `json:"blocked"`
+	Tagged     uint64 `json:"tagged"`
+	// Allowed counts the matched requests passed on anyway, by the first request grace or as verified bots.
+	Allowed uint64 `json:"allowed"`
+}
+
+// PatternStats are the blocked requests of a plugin instance by matched pattern, or by reason for matches
+// without a pattern (e.g. "default deny").
+type PatternStats struct {
+	Middleware string            `json:"middleware"`
+	Patterns   map[string]uint64 `json:"patterns"`
+}
+
+// decisionCounters count the decisions of matched requests. Patterns come from the configuration,
+// so their number is bounded.
+type decisionCounters struct {
+	mu       sync.Mutex
+	outcomes map[string]uint64
+	patterns map[string]uint64
+}
+
+func newDecisionCounters() *decisionCounters {
+	return &decisionCounters{
+		outcomes: map[string]uint64{},
+		patterns: map[string]uint64{},
+	}
+}
+
+// add counts the decision, and the pattern of blocked requests.
+func (counters *decisionCounters) add(outcome string, blockMatch *match) {
+	counters.mu.Lock()
+	defer counters.mu.Unlock()
+
+	counters.outcomes[outcome]++
+
+	if outcome != DecisionBlocked {
+		return
+	}
+
+	pattern := blockMatch.pattern
+	if pattern == "" {
+		pattern = blockMatch.reason
+	}
+
+	counters.patterns[pattern]++
+}
+
+// decide counts the decision of a matched request and records it, see recordDecision.
+func (blockUrls *traefik_block_regex_urls) decide(request *http.Request, outcome string, blockMatch *match) *http.Request {
+	blockUrls.counters.add(outcome, blockMatch)
+
+	return recordDecision(request, outcome, blockMatch)
+}
+
+// Stats returns the decision counters of the instance since it started.
+func (blockUrls *traefik_block_regex_urls) Stats() Stats {
+	blockUrls.counters.mu.Lock()
+	defer blockUrls.counters.mu.Unlock()
+
+	return Stats{
+		Middleware: blockUrls.name,
+		Blocked:    blockUrls.counters.outcomes[DecisionBlocked],
+		Tagged:     blockUrls.counters.outcomes[DecisionTagged],
+		Allowed:    blockUrls.counters.outcomes[DecisionAllowed],
+	}
+}
+
+// PatternStats returns how often each pattern blocked a request of the instance since it started.
+func (blockUrls *traefik_block_regex_urls) PatternStats() PatternStats {
+	blockUrls.counters.mu.Lock()
+	defer blockUrls.counters.mu.Unlock()
+
+	patterns := make(map[string]uint64, len(blockUrls.counters.patterns))
+	for pattern, count := range blockUrls.counters.patterns {
+		patterns[pattern] = count
+	}
+
+	return PatternStats{Middleware: blockUrls.name, Patterns: patterns}
+}
@@ -0,0 +1,74 @@
+package traefik_block_regex_urls_test
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+type statsReporter interface {
+	Stats() BlockUrls.Stats
+	PatternStats() BlockUrls.PatternStats
+}
+
+func newNamedHandler(t *testing.T, cfg *BlockUrls.Config, name string) statsReporter {
+	t.Helper()
+
+	handler, err := BlockUrls.New(context.Background(), nil, cfg, name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return handler.(statsReporter)
+}
+
+func Test_BlockUrls_Stats_NamespacedByInstance(t *testing.T) {
+	blogConfig := BlockUrls.CreateConfig()
+	blogConfig.Regex = []string{"(.*)/wp-login"}
+
+	apiConfig := BlockUrls.CreateConfig()
+	apiConfig.Regex = []string{"(.*)/\\.env"}
+	apiConfig.Mode = "tag"
+
+	blog := newNamedHandler(t, blogConfig, "blog")
+	api := newNamedHandler(t, apiConfig, "api")
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	serveRequest(t, blog.(http.Handler), "http://localhost/wp-login")
+	serveRequest(t, blog.(http.Handler), "http://localhost/wp-login?again")
+	serveRequest(t, blog.(http.Handler), "http://localhost/.env")
+	serveRequest(t, api.(http.Handler), "http://localhost/.env")
+
+	if stats := blog.Stats(); stats != (BlockUrls.Stats{Middleware: "blog", Blocked: 2}) {
+		t.Errorf("unexpected blog stats: %+v", stats)
+	}
+
+	if stats := api.Stats(); stats != (BlockUrls.Stats{Middleware: "api", Tagged: 1}) {
+		t.Errorf("unexpected api stats: %+v", stats)
+	}
+
+	blogPatterns := blog.PatternStats()
+	if blogPatterns.Middleware != "blog" || len(blogPatterns.Patterns) != 1 || blogPatterns.Patterns["(.*)/wp-login"] != 2 {
+		t.Errorf("unexpected blog pattern stats: %+v", blogPatterns)
+	}
+
+	// tagged requests are not blocked
+	if apiPatterns := api.PatternStats(); apiPatterns.Middleware != "api" || len(apiPatterns.Patterns) != 0 {
+		t.Errorf("unexpected api pattern stats: %+v", apiPatterns)
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		if !strings.HasSuffix(line, "middleware=blog") && !strings.HasSuffix(line, "middleware=api") {
+			t.Errorf("expected a log line tagged with the instance name, got %q", line)
+		}
+	}
+}
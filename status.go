@@ -0,0 +1,150 @@
+package traefik_block_regex_urls
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// Version of the plugin, reported by the status endpoint.
+const Version = "0.0.0"
+
+// status is the document served on the status path.
+type status struct {
+	Name     string         `json:"name"`
+	Version  string         `json:"version"`
+	Uptime   string         `json:"uptime"`
+	Rules    map[string]int `json:"rules"`
+	Patterns []string       `json:"patterns"`
+	// RecentBlocks is only set with recentBlocksCapacity.
+	RecentBlocks []BlockEvent `json:"recentBlocks,omitempty"`
+}
+
+// Rules returns the source of the regex values currently loaded, compiled or not, the regex list followed by the rules,
+// e.g. to check the effect of ApplyPatch on a running instance.
+func (blockUrls *traefik_block_regex_urls) Rules() []string {
+	blockUrls.mu.RLock()
+	defer blockUrls.mu.RUnlock()
+
+	patterns := make([]string, 0, len(blockUrls.lazyRegexps)+len(blockUrls.regexps)+len(blockUrls.rules))
+
+	// listed without compiling them
+	for _, lazy := range blockUrls.lazyRegexps {
+		patterns = append(patterns, lazy.pattern)
+	}
+
+	for _, regex := range blockUrls.regexps {
+		patterns = append(patterns, regex.String())
+	}
+
+	for _, configRule := range blockUrls.rules {
+		patterns = append(patterns, configRule.regex.String())
+	}
+
+	return patterns
+}
+
+// ruleCounts returns the number of loaded rules by kind.
+func (blockUrls *traefik_block_regex_urls) ruleCounts() map[string]int {
+	blockUrls.mu.RLock()
+	regexps := len(blockUrls.regexps) + len(blockUrls.lazyRegexps)
+	matchStrings := len(blockUrls.matchStrings)
+	blockUrls.mu.RUnlock()
+
+	return map[string]int{
+		"regex":               regexps,
+		"rules":               len(blockUrls.rules),
+		"exactMatch":          len(blockUrls.exactMatch),
+		"strings":             matchStrings,
+		"compositeRegex":      len(blockUrls.compositeRegexps),
+		"acceptRegex":         len(blockUrls.acceptRegexps),
+		"acceptLanguageRegex": len(blockUrls.acceptLanguageRegexps),
+	}
+}
+
+// summary describes the effective configuration in one line, e.g.
+// "regex=2 rules=0 strings=1 statusCode=403 mode=block matchScope=full allowedIPs=0 bypassIPs=0 features=[allowLocalRequests]".
+func (blockUrls *traefik_block_regex_urls) summary() string {
+	counts := blockUrls.ruleCounts()
+
+	features := []string{}
+	for feature, enabled := range map[string]bool{
+		"allowLocalRequests":       blockUrls.allowLocalRequests,
+		"skipIfAuthenticated":      blockUrls.skipIfAuthenticated,
+		"decodeQueryValues":        blockUrls.decodeQueryValues,
+		"matchEscapedFragment":     blockUrls.matchEscapedFragment,
+		"blockControlChars":        blockUrls.blockControlChars,
+		"blockInvalidUTF8Path":     blockUrls.blockInvalidUTF8Path,
+		"blockSmugglingIndicators": blockUrls.blockSmugglingIndicators,
+		"blockSNIHostMismatch":     blockUrls.blockSNIHostMismatch,
+		"expectHTTPS":              blockUrls.expectHTTPS,
+		"expectedHosts":            len(blockUrls.expectedHosts) > 0,
+		"blockedTLDs":              len(blockUrls.blockedTLDs) > 0,
+		"blockMixedCasePath":       blockUrls.blockMixedCasePath,
+		"blockHighEntropyPath":     blockUrls.blockHighEntropyPath,
+		"maxTraversalDepth":        blockUrls.maxTraversalDepth > 0,
+		"blockMissingAccept":       blockUrls.blockMissingAccept,
+		"graceFirstRequest":        blockUrls.graceTracker != nil,
+		"verifiedBots":             blockUrls.botVerifier != nil,
+		"activeWindow":             blockUrls.activeWindow != nil,
+		"trackTopBlocked":          blockUrls.topBlocked != nil,
+		"trackTopBlockedIPs":       blockUrls.topBlockedIPs != nil,
+		"recentBlocks":             blockUrls.recentBlocks != nil,
+		"defaultDeny":              blockUrls.defaultDeny,
+		"claimBlock":               len(blockUrls.claimBlocks) > 0,
+		"geoBlock":                 blockUrls.geoBlock != nil,
+		"skipExtensions":           len(blockUrls.skipExtensions) > 0,
+		"ports":                    len(blockUrls.ports) > 0,
+		"catchAll":                 blockUrls.catchAll != nil,
+		"orderAllowDeny":           blockUrls.order == orderAllowDeny,
+		"minInterval":              blockUrls.intervalTracker != nil,
+		"distinctURLThreshold":     blockUrls.distinctURLTracker != nil,
+		"auditFile":                blockUrls.auditLog != nil,
+		"rulesDir":                 blockUrls.hostRules != nil,
+		"maxConcurrent":            blockUrls.concurrencyLimit != nil,
+		"blockDelay":               blockUrls.blockDelay > 0 || blockUrls.blockDelayJitter > 0,
+		"disableStringMatch":       blockUrls.disableStringMatch,
+		"ahoCorasick":              blockUrls.ahoCorasick,
+		"echoOnBlock":              blockUrls.echoOnBlock,
+	} {
+		if enabled {
+			features = append(features, feature)
+		}
+	}
+
+	sort.Strings(features)
+
+	return fmt.Sprintf("regex=%d rules=%d ruleSets=%d exactMatch=%d strings=%d statusCode=%d mode=%s matchScope=%s allowedIPs=%d bypassIPs=%d features=%v",
+		counts["regex"], counts["rules"], len(blockUrls.ruleSets), counts["exactMatch"], counts["strings"],
+		blockUrls.statusCode, blockUrls.mode, blockUrls.matchScope, len(blockUrls.allowedIPs), len(blockUrls.bypassIPs), features)
+}
+
+// canSeeStatus reports whether the client may read the status, only when in the ip allowlist.
+// There is no fallback to private ranges, a client can claim one with X-Forwarded-For.
+func (blockUrls *traefik_block_regex_urls) canSeeStatus(client *requestClient) bool {
+	return blockUrls.isAllowedRequest(client)
+}
+
+// serveStatus writes the status document as json.
+func (blockUrls *traefik_block_regex_urls) serveStatus(responseWriter http.ResponseWriter) {
+	body, marshalError := json.Marshal(status{
+		Name:         blockUrls.name,
+		Version:      Version,
+		Uptime:       blockUrls.now().Sub(blockUrls.startedAt).Round(time.Second).String(),
+		Rules:        blockUrls.ruleCounts(),
+		Patterns:     blockUrls.Rules(),
+		RecentBlocks: blockUrls.RecentBlocks(-1),
+	})
+	if marshalError != nil {
+		blockUrls.logger.Printf("error encoding status: %v middleware=%s", marshalError, blockUrls.name)
+		responseWriter.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	responseWriter.Header().Set("Content-Type", "application/json")
+	responseWriter.Header().Set("Cache-Control", "no-store")
+	responseWriter.WriteHeader(http.StatusOK)
+	_, _ = responseWriter.Write(body)
+}
@@ -0,0 +1,209 @@
+package traefik_block_regex_urls_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+func Test_BlockUrls_StatusPath_ServesStatus(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login", "(.*)/xmlrpc.php"}
+	cfg.Strings = []string{".env"}
+	cfg.StatusPath = "/__block_status"
+	cfg.AllowedIPs = []string{"2.56.20.0/24"}
+
+	clock := newFakeClock()
+	nextCalled := false
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) { nextCalled = true })
+
+	handler, err := BlockUrls.NewWithOptions(context.Background(), next, cfg, "BlockUrls", BlockUrls.WithClock(clock.Now))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clock.Advance(90 * time.Second)
+
+	response := serveRequestWithHeaders(t, handler, "http://localhost/__block_status", map[string]string{"X-Forwarded-For": "2.56.20.1"})
+	assertStatusCode(t, response, http.StatusOK)
+
+	if nextCalled {
+		t.Error("the status path was passed to the next handler")
+	}
+
+	if contentType := response.Header.Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("invalid content type: %q", contentType)
+	}
+
+	var status struct {
+		Name     string         `json:"name"`
+		Version  string         `json:"version"`
+		Uptime   string         `json:"uptime"`
+		Rules    map[string]int `json:"rules"`
+		Patterns []string       `json:"patterns"`
+	}
+
+	if err := json.NewDecoder(response.Body).Decode(&status); err != nil {
+		t.Fatal(err)
+	}
+
+	if status.Name != "BlockUrls" || status.Version != BlockUrls.Version || status.Uptime != "1m30s" {
+		t.Errorf("unexpected status: %+v", status)
+	}
+
+	if status.Rules["regex"] != 2 || status.Rules["strings"] != 1 {
+		t.Errorf("unexpected rule counts: %v", status.Rules)
+	}
+
+	if !slices.Equal(status.Patterns, cfg.Regex) {
+		t.Errorf("unexpected patterns: %v", status.Patterns)
+	}
+}
+
+type rulesLister interface {
+	Rules() []string
+	ApplyPatch(add []string, remove []string) error
+}
+
+func Test_BlockUrls_Rules_ListsLoadedPatterns(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login", "(.*)/xmlrpc.php"}
+	cfg.Rules = []BlockUrls.Rule{{Regex: "(.*)/admin", StatusCode: 404}}
+
+	handler := newHandler(t, cfg).(rulesLister)
+
+	if rules := handler.Rules(); !slices.Equal(rules, []string{"(.*)/wp-login", "(.*)/xmlrpc.php", "(.*)/admin"}) {
+		t.Errorf("unexpected rules: %v", rules)
+	}
+
+	if err := handler.ApplyPatch([]string{`(.*)/\.git/`}, []string{"(.*)/xmlrpc.php"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if rules := handler.Rules(); !slices.Equal(rules, []string{"(.*)/wp-login", `(.*)/\.git/`, "(.*)/admin"}) {
+		t.Errorf("unexpected rules after patch: %v", rules)
+	}
+}
+
+func Test_BlockUrls_StatusPath_IsPassedOn_IfNotAllowed(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.StatusPath = "/__block_status"
+	cfg.AllowedIPs = []string{"2.56.20.0/24"}
+
+	nextCalled := false
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) { nextCalled = true })
+
+	handler, err := BlockUrls.New(context.Background(), next, cfg, "BlockUrls")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response := serveRequestWithHeaders(t, handler, "http://localhost/__block_status", map[string]string{"X-Forwarded-For": "8.8.8.8"})
+	assertStatusCode(t, response, http.StatusOK)
+
+	if !nextCalled {
+		t.Error("expected the status path to be passed on for a client outside the allowlist")
+	}
+}
+
+func Test_BlockUrls_StatusPath_IsDisabled_WithoutAllowedIPs(t *testing.T) {
+	var output bytes.Buffer
+
+	log.SetOutput(&output)
+	defer log.SetOutput(os.Stderr)
+
+	cfg := BlockUrls.CreateConfig()
+	cfg.StatusPath = "/__block_status"
+
+	nextCalled := false
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) { nextCalled = true })
+
+	handler, err := BlockUrls.New(context.Background(), next, cfg, "BlockUrls")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(output.String(), "Warning: statusPath without allowedIPs is disabled") {
+		t.Errorf("expected a warning, got %q", output.String())
+	}
+
+	// a spoofed private ip does not get the status
+	response := serveRequestWithHeaders(t, handler, "http://localhost/__block_status", map[string]string{"X-Forwarded-For": "127.0.0.1"})
+	assertStatusCode(t, response, http.StatusOK)
+
+	if !nextCalled {
+		t.Error("expected the status path to be passed on")
+	}
+}
+
+func Test_BlockUrls_AllowedIPs_DoNotBypassBlocking(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login"}
+	cfg.AllowedIPs = []string{"2.56.20.0/24"}
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	response := serveRequestWithHeaders(t, handler, "http://localhost/wp-login", map[string]string{"X-Forwarded-For": "2.56.20.7"})
+	assertStatusCode(t, response, http.StatusNotFound)
+}
+
+func Test_BlockUrls_BypassIPs_BypassBlocking(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login"}
+	cfg.BypassIPs = []string{"2.56.20.0/24", "2001:db8::1"}
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	tests := map[string]int{
+		"2.56.20.7":   http.StatusOK,
+		"2001:db8::1": http.StatusOK,
+		"2001:db8::2": http.StatusNotFound,
+		"8.8.8.8":     http.StatusNotFound,
+	}
+
+	for forwardedFor, expected := range tests {
+		response := serveRequestWithHeaders(t, handler, "http://localhost/wp-login", map[string]string{"X-Forwarded-For": forwardedFor})
+		assertStatusCode(t, response, expected)
+	}
+}
+
+func Test_BlockUrls_LogsSummary_IfNotSilent(t *testing.T) {
+	var output bytes.Buffer
+
+	log.SetOutput(&output)
+	defer log.SetOutput(os.Stderr)
+
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login", "(.*)/xmlrpc.php"}
+	cfg.Strings = []string{".env"}
+	cfg.AllowedIPs = []string{"2.56.20.0/24"}
+	cfg.AllowLocalRequests = true
+	cfg.MatchScope = "path"
+	cfg.StatusCode = 404
+
+	newHandler(t, cfg)
+
+	if output.Len() != 0 {
+		t.Errorf("expected a silent start up, got %q", output.String())
+	}
+
+	cfg.SilentStartUp = false
+
+	newHandler(t, cfg)
+
+	expected := "Loaded regex=2 rules=0 ruleSets=0 exactMatch=0 strings=1 statusCode=404 mode=block matchScope=path allowedIPs=1 bypassIPs=0 features=[allowLocalRequests]: middleware=BlockUrls"
+	if !strings.Contains(output.String(), expected) {
+		t.Errorf("summary %q not found in %q", expected, output.String())
+	}
+}
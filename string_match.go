@@ -0,0 +1,62 @@
+package traefik_block_regex_urls
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Modes comparing the strings against the match target.
+const (
+	// stringMatchContains matches a string anywhere in the target.
+	stringMatchContains = "contains"
+	// stringMatchPrefix matches a string at the start of the target.
+	stringMatchPrefix = "prefix"
+	// stringMatchSuffix matches a string at the end of the target.
+	stringMatchSuffix = "suffix"
+	// stringMatchExact matches a string equal to the target.
+	stringMatchExact = "exact"
+)
+
+// parseStringMatchMode validates a string match mode, empty falls back to contains.
+func parseStringMatchMode(mode string) (string, error) {
+	switch mode {
+	case "":
+		return stringMatchContains, nil
+	case stringMatchContains, stringMatchPrefix, stringMatchSuffix, stringMatchExact:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid stringMatchMode %q, expected %q, %q, %q or %q",
+			mode, stringMatchContains, stringMatchPrefix, stringMatchSuffix, stringMatchExact)
+	}
+}
+
+// matchesString reports whether the target matches the string in the string match mode.
+func (blockUrls *traefik_block_regex_urls) matchesString(target string, matchString string) bool {
+	switch blockUrls.stringMatchMode {
+	case stringMatchPrefix:
+		return strings.HasPrefix(target, matchString)
+	case stringMatchSuffix:
+		return strings.HasSuffix(target, matchString)
+	case stringMatchExact:
+		return target == matchString
+	default:
+		return strings.Contains(target, matchString)
+	}
+}
+
+// stringPattern returns the string as an escaped literal, anchored according to the string match mode.
+func stringPattern(mode string, matchString string) string {
+	literal := regexp.QuoteMeta(matchString)
+
+	switch mode {
+	case stringMatchPrefix:
+		return `^` + literal
+	case stringMatchSuffix:
+		return literal + `$`
+	case stringMatchExact:
+		return `^` + literal + `$`
+	default:
+		return literal
+	}
+}
@@ -0,0 +1,52 @@
+package traefik_block_regex_urls_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+func Test_BlockUrls_StringMatchMode(t *testing.T) {
+	// the same input, /wp-login.php in the path scope, against strings matching one part of it each
+	tests := []struct {
+		mode     string
+		expected map[string]int
+	}{
+		{mode: "", expected: map[string]int{"login": http.StatusForbidden, "/wp-login": http.StatusForbidden, ".php": http.StatusForbidden, "/wp-login.php": http.StatusForbidden}},
+		{mode: "contains", expected: map[string]int{"login": http.StatusForbidden, "/wp-login": http.StatusForbidden, ".php": http.StatusForbidden, "/wp-login.php": http.StatusForbidden}},
+		{mode: "prefix", expected: map[string]int{"login": http.StatusOK, "/wp-login": http.StatusForbidden, ".php": http.StatusOK, "/wp-login.php": http.StatusForbidden}},
+		{mode: "suffix", expected: map[string]int{"login": http.StatusOK, "/wp-login": http.StatusOK, ".php": http.StatusForbidden, "/wp-login.php": http.StatusForbidden}},
+		{mode: "exact", expected: map[string]int{"login": http.StatusOK, "/wp-login": http.StatusOK, ".php": http.StatusOK, "/wp-login.php": http.StatusForbidden}},
+	}
+
+	for _, test := range tests {
+		for matchString, expected := range test.expected {
+			for _, combined := range []bool{false, true} {
+				cfg := BlockUrls.CreateConfig()
+				cfg.MatchScope = "path"
+				cfg.Strings = []string{matchString}
+				cfg.StringMatchMode = test.mode
+				cfg.CombineRegex = combined
+				cfg.IncludeStringsInCombined = combined
+
+				res := serveRequest(t, newHandler(t, cfg), "http://localhost/wp-login.php")
+
+				if res.StatusCode != expected {
+					t.Errorf("mode %q, string %q, combined %t: expected status %d, got %d", test.mode, matchString, combined, expected, res.StatusCode)
+				}
+			}
+		}
+	}
+}
+
+func Test_BlockUrls_StringMatchMode_Invalid(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.StringMatchMode = "regex"
+
+	_, err := BlockUrls.New(context.Background(), nil, cfg, "BlockUrls")
+	if err == nil {
+		t.Fatal("expected an error for an invalid stringMatchMode")
+	}
+}
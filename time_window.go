@@ -0,0 +1,64 @@
+package traefik_block_regex_urls
+
+import (
+	"fmt"
+	"time"
+)
+
+// timeWindow is a daily window in which the rules are enforced, as minutes since midnight.
+// A window with from > to spans midnight.
+type timeWindow struct {
+	from     int
+	to       int
+	location *time.Location
+}
+
+// newTimeWindow parses the HH:MM bounds of the window in the given IANA timezone, UTC if empty.
+func newTimeWindow(from string, to string, timezone string) (*timeWindow, error) {
+	fromMinutes, parseError := parseClock(from)
+	if parseError != nil {
+		return nil, fmt.Errorf("invalid activeFrom %q: %w", from, parseError)
+	}
+
+	toMinutes, parseError := parseClock(to)
+	if parseError != nil {
+		return nil, fmt.Errorf("invalid activeTo %q: %w", to, parseError)
+	}
+
+	location := time.UTC
+	if timezone != "" {
+		loadedLocation, loadError := time.LoadLocation(timezone)
+		if loadError != nil {
+			return nil, fmt.Errorf("invalid activeTimezone %q: %w", timezone, loadError)
+		}
+
+		location = loadedLocation
+	}
+
+	return &timeWindow{from: fromMinutes, to: toMinutes, location: location}, nil
+}
+
+// parseClock returns the minutes since midnight of a HH:MM value.
+func parseClock(value string) (int, error) {
+	parsed, parseError := time.Parse("15:04", value)
+	if parseError != nil {
+		return 0, parseError
+	}
+
+	return parsed.Hour()*60 + parsed.Minute(), nil
+}
+
+// contains reports whether the instant falls into the window. Equal bounds mean the whole day.
+func (window *timeWindow) contains(instant time.Time) bool {
+	local := instant.In(window.location)
+	minutes := local.Hour()*60 + local.Minute()
+
+	switch {
+	case window.from == window.to:
+		return true
+	case window.from < window.to:
+		return minutes >= window.from && minutes < window.to
+	default:
+		return minutes >= window.from || minutes < window.to
+	}
+}
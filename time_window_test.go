@@ -0,0 +1,136 @@
+package traefik_block_regex_urls_test
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+func Test_BlockUrls_ActiveWindow(t *testing.T) {
+	tests := []struct {
+		from     string
+		to       string
+		clock    string
+		expected int
+	}{
+		{"09:00", "17:00", "2025-06-02T12:00:00Z", http.StatusNotFound},
+		{"09:00", "17:00", "2025-06-02T17:00:00Z", http.StatusOK},
+		{"09:00", "17:00", "2025-06-02T08:59:00Z", http.StatusOK},
+		{"22:00", "06:00", "2025-06-02T23:30:00Z", http.StatusNotFound},
+		{"22:00", "06:00", "2025-06-02T05:59:00Z", http.StatusNotFound},
+		{"22:00", "06:00", "2025-06-02T12:00:00Z", http.StatusOK},
+	}
+
+	for _, test := range tests {
+		cfg := BlockUrls.CreateConfig()
+		cfg.Regex = []string{"(.*)/admin"}
+		cfg.ActiveFrom = test.from
+		cfg.ActiveTo = test.to
+		cfg.StatusCode = 404
+
+		clock, err := time.Parse(time.RFC3339, test.clock)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+		handler, err := BlockUrls.NewWithOptions(context.Background(), next, cfg, "BlockUrls", BlockUrls.WithClock(func() time.Time { return clock }))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assertStatusCode(t, serveRequest(t, handler, "http://localhost/admin"), test.expected)
+	}
+}
+
+func Test_BlockUrls_ActiveWindow_HonorsTimezone(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/admin"}
+	cfg.ActiveFrom = "09:00"
+	cfg.ActiveTo = "17:00"
+	cfg.ActiveTimezone = "Asia/Kolkata"
+	cfg.StatusCode = 404
+
+	// 04:00 UTC is 09:30 in Asia/Kolkata
+	clock := time.Date(2025, 6, 2, 4, 0, 0, 0, time.UTC)
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	handler, err := BlockUrls.NewWithOptions(context.Background(), next, cfg, "BlockUrls", BlockUrls.WithClock(func() time.Time { return clock }))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/admin"), http.StatusNotFound)
+}
+
+func Test_BlockUrls_ActiveWindow_ReturnsError_IfInvalid(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.ActiveFrom = "9am"
+	cfg.ActiveTo = "17:00"
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	if _, err := BlockUrls.New(context.Background(), next, cfg, "BlockUrls"); err == nil {
+		t.Fatal("expected an error for an invalid activeFrom")
+	}
+}
+
+func Test_BlockUrls_StartupGrace(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/admin"}
+	cfg.StartupGrace = "30s"
+	cfg.StatusCode = 404
+
+	clock := newFakeClock()
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	handler, err := BlockUrls.NewWithOptions(context.Background(), next, cfg, "BlockUrls", BlockUrls.WithClock(clock.Now))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var output bytes.Buffer
+
+	log.SetOutput(&output)
+	defer log.SetOutput(os.Stderr)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/admin"), http.StatusOK)
+
+	clock.Advance(29 * time.Second)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/admin"), http.StatusOK)
+
+	if output.Len() != 0 {
+		t.Errorf("expected no log line during the grace, got %q", output.String())
+	}
+
+	clock.Advance(time.Second)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/admin"), http.StatusNotFound)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/admin"), http.StatusNotFound)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/index.html"), http.StatusOK)
+
+	if strings.Count(output.String(), "Startup grace of 30s elapsed, enforcing the rules") != 1 {
+		t.Errorf("expected the start of enforcement to be logged once, got %q", output.String())
+	}
+}
+
+func Test_BlockUrls_StartupGrace_Invalid(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.StartupGrace = "soon"
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	if _, err := BlockUrls.New(context.Background(), next, cfg, "BlockUrls"); err == nil {
+		t.Fatal("expected an error for an invalid startupGrace")
+	}
+}
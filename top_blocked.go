@@ -0,0 +1,178 @@
+package traefik_block_regex_urls
+
+import (
+	"container/heap"
+	"sort"
+	"sync"
+)
+
+// defaultTopBlockedMaxEntries bounds the number of distinct urls tracked for TopBlocked, and of IPs for TopBlockedIPs.
+const defaultTopBlockedMaxEntries = 1000
+
+// BlockedURL is a blocked url and how often it was blocked.
+type BlockedURL struct {
+	URL   string
+	Count uint64
+}
+
+// BlockedIP is a client IP and how often its requests were blocked.
+type BlockedIP struct {
+	IP    string
+	Count uint64
+}
+
+// topCounter counts keys in a map of bounded size.
+// When full, the least counted key is evicted and its count inherited by the new key (space-saving),
+// so frequent keys stay tracked and counts are upper bounds. A min-heap of the entries finds the least counted key.
+type topCounter struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*topEntry
+	byCount    topHeap
+}
+
+// topEntry is a counted key and its position in the heap.
+type topEntry struct {
+	key   string
+	count uint64
+	index int
+}
+
+// topHeap orders the entries least counted first, see container/heap.
+type topHeap []*topEntry
+
+func (entries topHeap) Len() int { return len(entries) }
+
+func (entries topHeap) Less(i, j int) bool { return entries[i].count < entries[j].count }
+
+func (entries topHeap) Swap(i, j int) {
+	entries[i], entries[j] = entries[j], entries[i]
+	entries[i].index = i
+	entries[j].index = j
+}
+
+func (entries *topHeap) Push(value any) {
+	entry := value.(*topEntry)
+	entry.index = len(*entries)
+	*entries = append(*entries, entry)
+}
+
+func (entries *topHeap) Pop() any {
+	old := *entries
+	entry := old[len(old)-1]
+	*entries = old[:len(old)-1]
+
+	return entry
+}
+
+func newTopCounter(maxEntries int) *topCounter {
+	return &topCounter{
+		maxEntries: maxEntries,
+		entries:    map[string]*topEntry{},
+	}
+}
+
+func (counter *topCounter) add(key string) {
+	counter.mu.Lock()
+	defer counter.mu.Unlock()
+
+	if entry, found := counter.entries[key]; found {
+		entry.count++
+		heap.Fix(&counter.byCount, entry.index)
+
+		return
+	}
+
+	if len(counter.entries) < counter.maxEntries {
+		entry := &topEntry{key: key, count: 1}
+		counter.entries[key] = entry
+		heap.Push(&counter.byCount, entry)
+
+		return
+	}
+
+	// the least counted entry is taken over by the new key
+	entry := counter.byCount[0]
+	delete(counter.entries, entry.key)
+
+	entry.key = key
+	entry.count++
+	counter.entries[key] = entry
+	heap.Fix(&counter.byCount, 0)
+}
+
+// counts returns a copy of the counts.
+func (counter *topCounter) counts() map[string]uint64 {
+	counter.mu.Lock()
+	defer counter.mu.Unlock()
+
+	counts := make(map[string]uint64, len(counter.entries))
+	for key, entry := range counter.entries {
+		counts[key] = entry.count
+	}
+
+	return counts
+}
+
+// top returns the n most counted keys with their counts, most counted first.
+func (counter *topCounter) top(n int) ([]string, []uint64) {
+	counts := counter.counts()
+
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+
+		return keys[i] < keys[j]
+	})
+
+	if n >= 0 && n < len(keys) {
+		keys = keys[:n]
+	}
+
+	topCounts := make([]uint64, len(keys))
+	for index, key := range keys {
+		topCounts[index] = counts[key]
+	}
+
+	return keys, topCounts
+}
+
+// TopBlocked returns the n most blocked urls, most blocked first.
+// Returns nil if tracking is not enabled with trackTopBlocked.
+func (blockUrls *traefik_block_regex_urls) TopBlocked(n int) []BlockedURL {
+	if blockUrls.topBlocked == nil {
+		return nil
+	}
+
+	urls, counts := blockUrls.topBlocked.top(n)
+
+	topBlocked := make([]BlockedURL, len(urls))
+	for index, url := range urls {
+		topBlocked[index] = BlockedURL{URL: url, Count: counts[index]}
+	}
+
+	return topBlocked
+}
+
+// TopBlockedIPs returns the n client IPs with the most blocked requests, most blocked first.
+// Returns nil if tracking is not enabled with trackTopBlockedIPs.
+func (blockUrls *traefik_block_regex_urls) TopBlockedIPs(n int) []BlockedIP {
+	if blockUrls.topBlockedIPs == nil {
+		return nil
+	}
+
+	ips, counts := blockUrls.topBlockedIPs.top(n)
+
+	topBlockedIPs := make([]BlockedIP, len(ips))
+	for index, ip := range ips {
+		topBlockedIPs[index] = BlockedIP{IP: ip, Count: counts[index]}
+	}
+
+	return topBlockedIPs
+}
@@ -0,0 +1,171 @@
+package traefik_block_regex_urls_test
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+type topBlockedReporter interface {
+	TopBlocked(n int) []BlockUrls.BlockedURL
+}
+
+func Test_BlockUrls_TopBlocked_OrdersByCount(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp(.*)", "(.*)/.env"}
+	cfg.TrackTopBlocked = true
+
+	handler := newHandler(t, cfg)
+
+	requests := map[string]int{
+		"http://localhost/wp-login.php": 5,
+		"http://localhost/.env":         3,
+		"http://localhost/wp-admin":     1,
+		"http://localhost/index.html":   7,
+	}
+
+	for url, times := range requests {
+		for i := 0; i < times; i++ {
+			serveRequest(t, handler, url)
+		}
+	}
+
+	expected := []BlockUrls.BlockedURL{
+		{URL: "localhost/wp-login.php", Count: 5},
+		{URL: "localhost/.env", Count: 3},
+	}
+
+	if topBlocked := handler.(topBlockedReporter).TopBlocked(2); !reflect.DeepEqual(topBlocked, expected) {
+		t.Errorf("unexpected top blocked: %v", topBlocked)
+	}
+
+	if topBlocked := handler.(topBlockedReporter).TopBlocked(10); len(topBlocked) != 3 {
+		t.Errorf("expected 3 tracked urls, got %v", topBlocked)
+	}
+}
+
+func Test_BlockUrls_TopBlocked_BoundsEntries(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/probe(.*)"}
+	cfg.TrackTopBlocked = true
+	cfg.TopBlockedMaxEntries = 10
+
+	handler := newHandler(t, cfg)
+
+	for i := 0; i < 20; i++ {
+		serveRequest(t, handler, "http://localhost/probe-frequent")
+	}
+
+	for i := 0; i < 100; i++ {
+		serveRequest(t, handler, fmt.Sprintf("http://localhost/probe-%d", i))
+	}
+
+	topBlocked := handler.(topBlockedReporter).TopBlocked(-1)
+
+	if len(topBlocked) != 10 {
+		t.Errorf("expected 10 tracked urls, got %d", len(topBlocked))
+	}
+
+	if topBlocked[0].URL != "localhost/probe-frequent" || topBlocked[0].Count != 20 {
+		t.Errorf("expected the frequent url to stay on top, got %v", topBlocked[0])
+	}
+}
+
+func Test_BlockUrls_TopBlocked_ReturnsNil_IfDisabled(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp(.*)"}
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-login.php"), http.StatusForbidden)
+
+	if topBlocked := handler.(topBlockedReporter).TopBlocked(10); topBlocked != nil {
+		t.Errorf("expected no tracking, got %v", topBlocked)
+	}
+}
+
+type topBlockedIPsReporter interface {
+	TopBlockedIPs(n int) []BlockUrls.BlockedIP
+}
+
+func Test_BlockUrls_TopBlockedIPs_OrdersByCount(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp(.*)"}
+	cfg.TrackTopBlockedIPs = true
+
+	handler := newHandler(t, cfg)
+
+	requests := map[string]int{
+		"203.0.113.1": 2,
+		"203.0.113.2": 6,
+		"203.0.113.3": 4,
+	}
+
+	for ip, times := range requests {
+		for i := 0; i < times; i++ {
+			serveRequestWithHeaders(t, handler, "http://localhost/wp-login.php", map[string]string{"X-Forwarded-For": ip})
+		}
+
+		serveRequestWithHeaders(t, handler, "http://localhost/index.html", map[string]string{"X-Forwarded-For": ip})
+	}
+
+	expected := []BlockUrls.BlockedIP{
+		{IP: "203.0.113.2", Count: 6},
+		{IP: "203.0.113.3", Count: 4},
+	}
+
+	if topBlockedIPs := handler.(topBlockedIPsReporter).TopBlockedIPs(2); !reflect.DeepEqual(topBlockedIPs, expected) {
+		t.Errorf("unexpected top blocked ips: %v", topBlockedIPs)
+	}
+
+	if topBlocked := handler.(topBlockedReporter).TopBlocked(10); topBlocked != nil {
+		t.Errorf("expected no url tracking, got %v", topBlocked)
+	}
+}
+
+func Test_BlockUrls_TopBlockedIPs_SkipsUnknownIPs(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp(.*)"}
+	cfg.TrackTopBlockedIPs = true
+
+	handler := newHandler(t, cfg)
+
+	serveRequest(t, handler, "http://localhost/wp-login.php")
+	serveRequestWithHeaders(t, handler, "http://localhost/wp-login.php", map[string]string{"X-Forwarded-For": "203.0.113.1"})
+
+	expected := []BlockUrls.BlockedIP{{IP: "203.0.113.1", Count: 1}}
+
+	if topBlockedIPs := handler.(topBlockedIPsReporter).TopBlockedIPs(-1); !reflect.DeepEqual(topBlockedIPs, expected) {
+		t.Errorf("unexpected top blocked ips: %v", topBlockedIPs)
+	}
+}
+
+func Test_BlockUrls_TopBlocked_EvictsLeastCounted(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/probe(.*)"}
+	cfg.TrackTopBlocked = true
+	cfg.TopBlockedMaxEntries = 2
+
+	handler := newHandler(t, cfg)
+
+	for url, times := range map[string]int{"http://localhost/probe-a": 3, "http://localhost/probe-b": 1} {
+		for i := 0; i < times; i++ {
+			serveRequest(t, handler, url)
+		}
+	}
+
+	// probe-c takes over the count of probe-b, the least counted
+	serveRequest(t, handler, "http://localhost/probe-c")
+
+	expected := []BlockUrls.BlockedURL{
+		{URL: "localhost/probe-a", Count: 3},
+		{URL: "localhost/probe-c", Count: 2},
+	}
+
+	if topBlocked := handler.(topBlockedReporter).TopBlocked(-1); !reflect.DeepEqual(topBlocked, expected) {
+		t.Errorf("unexpected top blocked: %v", topBlocked)
+	}
+}
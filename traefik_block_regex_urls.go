@@ -5,9 +5,16 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"math/rand"
+	"net"
 	"net/http"
+	"net/url"
+	"path"
 	"regexp"
 	"slices"
+	"strings"
+	"sync"
+	"time"
 )
 
 /**********************************
@@ -17,19 +24,370 @@ import (
 type traefik_block_regex_urls struct {
 	next          http.Handler
 	name          string
-	regexps       []*regexp.Regexp
+	enabled       bool
+	rules         []*rule
+	ruleSets      []*ruleSet
+	allOf         []*allOf
+	hostRules     *hostRules
 	exactMatch    []string
 	silentStartUp bool
+	logger        *log.Logger
 	statusCode    int
+
+	treatHeadAsGet bool
+	neverBlockRoot bool
+	skipExtensions []string
+	ports          []int
+
+	blockBody        string
+	blockContentType string
+	useHTTPError     bool
+	httpErrorMessage string
+	echoOnBlock      bool
+	gzipBlockBody    bool
+	gzipMinBytes     int
+	bodyTemplate     string
+	negotiatedTypes  []string
+	negotiatedBodies map[string]string
+	prefixResponses  []prefixResponse
+	action           string
+	decoyBody        string
+	decoyContentType string
+	rewritePath      string
+
+	decodeQueryValues       bool
+	doubleDecodeQueryValues bool
+	matchEscapedFragment    bool
+	blockQueryKeys          []string
+
+	allowLocalRequests bool
+	allowedIPs         []*net.IPNet
+	bypassIPs          []*net.IPNet
+	auditAllowlisted   bool
+	allowRegexps       []*regexp.Regexp
+	allowQueryStrings  []string
+	defaultDeny        bool
+	catchAll           *rule
+	order              string
+
+	skipIfAuthenticated bool
+	sessionCookie       string
+	maxForwardedIPs     int
+	forwardedIPDepth    int
+	trustedIPHeader     *trustedIPHeader
+	privateIPBlocks     []*net.IPNet
+
+	acceptRegexps         []*regexp.Regexp
+	acceptLanguageRegexps []*regexp.Regexp
+	blockMissingAccept    bool
+
+	scanAllHeaders     bool
+	scanHeaders        []string
+	scanHeadersExclude []string
+	headerScanRegexps  []*regexp.Regexp
+	headerScanMaxBytes int
+	trailerRegexps     []*regexp.Regexp
+
+	formFields   []formField
+	formMaxBytes int
+	claimBlocks  []claimBlock
+	geoBlock     *geoBlock
+
+	fingerprintHeader   string
+	blockedFingerprints []string
+	fingerprintRegexps  []*regexp.Regexp
+
+	clientCertCNRegexps      []*regexp.Regexp
+	allowClientCertCNRegexps []*regexp.Regexp
+
+	graceTracker       *graceTracker
+	intervalTracker    *intervalTracker
+	distinctURLTracker *distinctURLTracker
+	botVerifier        *botVerifier
+	activeWindow       *timeWindow
+
+	startupGrace       time.Duration
+	enforcementStarted sync.Once
+
+	statusPath    string
+	debugEvalPath string
+	startedAt     time.Time
+	topBlocked    *topCounter
+	topBlockedIPs *topCounter
+	recentBlocks  *recentBlocks
+	auditLog      *auditLog
+
+	matchers         []Matcher
+	blockHandler     http.Handler
+	shadowRules      *shadowRules
+	concurrencyLimit *concurrencyLimit
+	events           chan<- BlockEvent
+
+	// now is the time source, replaceable in tests
+	now func() time.Time
+	// randInt63n is the random source of the block delay jitter, replaceable in tests
+	randInt63n func(n int64) int64
+
+	blockDelay       time.Duration
+	blockDelayJitter time.Duration
+
+	blockControlChars        bool
+	blockInvalidUTF8Path     bool
+	blockSmugglingIndicators bool
+	blockSNIHostMismatch     bool
+	expectedHosts            []string
+	blockedTLDs              []string
+	expectHTTPS              bool
+	expectHTTPSRedirect      bool
+	blockMixedCasePath       bool
+	mixedCaseMaxPercent      int
+	maxTraversalDepth        int
+	blockHighEntropyPath     bool
+	pathEntropyThreshold     float64
+	pathEntropyMinLength     int
+
+	compositeFormat  string
+	compositeRegexps []*regexp.Regexp
+
+	counters *decisionCounters
+
+	mode             string
+	reasonHeader     string
+	reportAllMatches bool
+	matchScope       string
+
+	trimLeadingSlash bool
+	includeFragment  bool
+	normalizeHost    bool
+	collapseSlashes  bool
+
+	combineRegex             bool
+	includeStringsInCombined bool
+	disableStringMatch       bool
+	stringMatchMode          string
+	ahoCorasick              bool
+	maxRules                 int
+	maxRulesAction           string
+
+	// mu guards the fields that may be swapped at runtime by file reloads.
+	mu           sync.RWMutex
+	regexps      []*regexp.Regexp
+	matchStrings []string
+	automaton    *ahoCorasick
+	combined     *regexp.Regexp
+	// lazyRegexps are the regex values of lazyCompile not compiled yet, in front of regexps.
+	lazyRegexps []*lazyRegex
+	denyFeed    []*net.IPNet
+	// staticPatterns counts the block patterns limited by maxRules other than the strings file entries
+	// (fileStrings), the deny feed and the rulesDir host files.
+	staticPatterns int
+	fileStrings    int
+}
+
+// rule is a compiled Rule.
+type rule struct {
+	regex       *regexp.Regexp
+	statusCode  int
+	body        string
+	contentType string
+	silent      bool
+	action      string
+	rewritePath string
+	methods     []string
+	rate        *ruleRate
+	// blockedBy is the url of the authority requiring the block, see GeoBlock.
+	blockedBy string
+}
+
+// match describes why a request is blocked.
+type match struct {
+	reason  string
+	url     string
+	pattern string
+	rule    *rule
+	// clientIP is the client ip of the blocked request, set by block for the response.
+	clientIP string
+}
+
+// Rule is a regex with its own response settings, falling back to the global ones when unset.
+type Rule struct {
+	Regex       string `yaml:"regex"`
+	StatusCode  int    `yaml:"statusCode,omitempty"`
+	Body        string `yaml:"body,omitempty"`
+	ContentType string `yaml:"contentType,omitempty"`
+	// Log toggles the block log line of the rule, nil means true.
+	Log *bool `yaml:"log,omitempty"`
+	// Action overrides the global action for the rule.
+	Action      string `yaml:"action,omitempty"`
+	RewritePath string `yaml:"rewritePath,omitempty"`
+	// Methods restricts the rule to these request methods, empty means all.
+	Methods []string `yaml:"methods,omitempty"`
+	// RateLimit only blocks once the rule matched more than this many requests of all clients
+	// within RateWindow (default 1m), zero means always.
+	RateLimit  int    `yaml:"rateLimit,omitempty"`
+	RateWindow string `yaml:"rateWindow,omitempty"`
 }
 
 type Config struct {
-	Regex         []string `yaml:"regex,omitempty"`
-	ExactMatch    []string `mapstructure:"exact_match,omitempty"`
-	SilentStartUp bool     `yaml:"silentStartUp"`
-	StatusCode    int      `yaml:"statusCode"`
+	Enabled                   bool                `yaml:"enabled"`
+	Regex                     []string            `yaml:"regex,omitempty"`
+	CombineRegex              bool                `yaml:"combineRegex,omitempty"`
+	IncludeStringsInCombined  bool                `yaml:"includeStringsInCombined,omitempty"`
+	DisableStringMatch        bool                `yaml:"disableStringMatch,omitempty"`
+	StringMatchMode           string              `yaml:"stringMatchMode,omitempty"`
+	AhoCorasick               bool                `yaml:"ahoCorasick,omitempty"`
+	LazyCompile               bool                `yaml:"lazyCompile,omitempty"`
+	Rules                     []Rule              `yaml:"rules,omitempty"`
+	MaxRules                  int                 `yaml:"maxRules,omitempty"`
+	MaxRulesAction            string              `yaml:"maxRulesAction,omitempty"`
+	RuleSets                  []RuleSet           `yaml:"ruleSets,omitempty"`
+	AllOf                     []AllOf             `yaml:"allOf,omitempty"`
+	ExactMatch                []string            `mapstructure:"exact_match,omitempty"`
+	Strings                   []string            `yaml:"strings,omitempty"`
+	MaxConcurrent             int                 `yaml:"maxConcurrent,omitempty"`
+	MaxConcurrentWait         string              `yaml:"maxConcurrentWait,omitempty"`
+	MaxConcurrentStatusCode   int                 `yaml:"maxConcurrentStatusCode,omitempty"`
+	RulesDir                  string              `yaml:"rulesDir,omitempty"`
+	StringsFile               string              `yaml:"stringsFile,omitempty"`
+	StringsFileReloadInterval string              `yaml:"stringsFileReloadInterval,omitempty"`
+	AllowedIPs                []string            `yaml:"allowedIPs,omitempty"`
+	BypassIPs                 []string            `yaml:"bypassIPs,omitempty"`
+	AuditAllowlistedMatches   bool                `yaml:"auditAllowlistedMatches,omitempty"`
+	AllowRegex                []string            `yaml:"allowRegex,omitempty"`
+	AllowQueryStrings         []string            `yaml:"allowQueryStrings,omitempty"`
+	DefaultDeny               bool                `yaml:"defaultDeny,omitempty"`
+	CatchAll                  *CatchAll           `yaml:"catchAll,omitempty"`
+	Order                     string              `yaml:"order,omitempty"`
+	DenyFeedURL               string              `yaml:"denyFeedURL,omitempty"`
+	DenyFeedRefreshInterval   string              `yaml:"denyFeedRefreshInterval,omitempty"`
+	SkipIfAuthenticated       bool                `yaml:"skipIfAuthenticated,omitempty"`
+	SessionCookie             string              `yaml:"sessionCookie,omitempty"`
+	AllowLocalRequests        bool                `yaml:"allowLocalRequests,omitempty"`
+	IncludeIPv6ULA            bool                `yaml:"includeIPv6ULA"`
+	IncludeLinkLocal          bool                `yaml:"includeLinkLocal"`
+	MaxForwardedIPs           int                 `yaml:"maxForwardedIPs,omitempty"`
+	ForwardedIPDepth          int                 `yaml:"forwardedIPDepth,omitempty"`
+	TrustedIPHeader           string              `yaml:"trustedIPHeader,omitempty"`
+	TrustedIPSignatureHeader  string              `yaml:"trustedIPSignatureHeader,omitempty"`
+	TrustedIPSecret           string              `yaml:"trustedIPSecret,omitempty"`
+	AcceptRegex               []string            `yaml:"acceptRegex,omitempty"`
+	AcceptLanguageRegex       []string            `yaml:"acceptLanguageRegex,omitempty"`
+	BlockMissingAccept        bool                `yaml:"blockMissingAccept,omitempty"`
+	ScanAllHeaders            bool                `yaml:"scanAllHeaders,omitempty"`
+	ScanHeaders               []string            `yaml:"scanHeaders,omitempty"`
+	ScanHeadersExclude        []string            `yaml:"scanHeadersExclude,omitempty"`
+	HeaderScanRegex           []string            `yaml:"headerScanRegex,omitempty"`
+	HeaderScanMaxBytes        int                 `yaml:"headerScanMaxBytes,omitempty"`
+	TrailerRegex              []string            `yaml:"trailerRegex,omitempty"`
+	FormFieldRegex            map[string][]string `yaml:"formFieldRegex,omitempty"`
+	FormMaxBytes              int                 `yaml:"formMaxBytes,omitempty"`
+	ClaimBlock                map[string][]string `yaml:"claimBlock,omitempty"`
+	GeoBlock                  *GeoBlock           `yaml:"geoBlock,omitempty"`
+	FingerprintHeader         string              `yaml:"fingerprintHeader,omitempty"`
+	BlockedFingerprints       []string            `yaml:"blockedFingerprints,omitempty"`
+	ClientCertCNRegex         []string            `yaml:"clientCertCNRegex,omitempty"`
+	AllowClientCertCNRegex    []string            `yaml:"allowClientCertCNRegex,omitempty"`
+	BlockControlChars         bool                `yaml:"blockControlChars,omitempty"`
+	BlockInvalidUTF8Path      bool                `yaml:"blockInvalidUTF8Path,omitempty"`
+	BlockSmugglingIndicators  bool                `yaml:"blockSmugglingIndicators,omitempty"`
+	BlockSNIHostMismatch      bool                `yaml:"blockSNIHostMismatch,omitempty"`
+	ExpectedHosts             []string            `yaml:"expectedHosts,omitempty"`
+	BlockedTLDs               []string            `yaml:"blockedTLDs,omitempty"`
+	ExpectHTTPS               bool                `yaml:"expectHTTPS,omitempty"`
+	ExpectHTTPSRedirect       bool                `yaml:"expectHTTPSRedirect,omitempty"`
+	BlockMixedCasePath        bool                `yaml:"blockMixedCasePath,omitempty"`
+	MixedCaseMaxPercent       int                 `yaml:"mixedCaseMaxPercent,omitempty"`
+	MaxTraversalDepth         int                 `yaml:"maxTraversalDepth,omitempty"`
+	BlockHighEntropyPath      bool                `yaml:"blockHighEntropyPath,omitempty"`
+	PathEntropyThreshold      float64             `yaml:"pathEntropyThreshold,omitempty"`
+	PathEntropyMinLength      int                 `yaml:"pathEntropyMinLength,omitempty"`
+	CompositeFormat           string              `yaml:"compositeFormat,omitempty"`
+	CompositeRegex            []string            `yaml:"compositeRegex,omitempty"`
+	VerifiedBots              []VerifiedBot       `yaml:"verifiedBots,omitempty"`
+	VerifiedBotsCacheTTL      string              `yaml:"verifiedBotsCacheTTL,omitempty"`
+	VerifiedBotsMaxEntries    int                 `yaml:"verifiedBotsMaxEntries,omitempty"`
+	ActiveFrom                string              `yaml:"activeFrom,omitempty"`
+	ActiveTo                  string              `yaml:"activeTo,omitempty"`
+	ActiveTimezone            string              `yaml:"activeTimezone,omitempty"`
+	GraceFirstRequest         bool                `yaml:"graceFirstRequest,omitempty"`
+	GraceTTL                  string              `yaml:"graceTTL,omitempty"`
+	GraceMaxEntries           int                 `yaml:"graceMaxEntries,omitempty"`
+	MinInterval               string              `yaml:"minInterval,omitempty"`
+	MinIntervalMaxEntries     int                 `yaml:"minIntervalMaxEntries,omitempty"`
+	DistinctURLThreshold      int                 `yaml:"distinctURLThreshold,omitempty"`
+	DistinctURLWindow         string              `yaml:"distinctURLWindow,omitempty"`
+	DistinctURLMaxEntries     int                 `yaml:"distinctURLMaxEntries,omitempty"`
+	StartupGrace              string              `yaml:"startupGrace,omitempty"`
+	MatchScope                string              `yaml:"matchScope,omitempty"`
+	IncludeFragment           bool                `yaml:"includeFragment,omitempty"`
+	NormalizeHost             bool                `yaml:"normalizeHost,omitempty"`
+	TrimLeadingSlash          bool                `yaml:"trimLeadingSlash,omitempty"`
+	CollapseSlashes           bool                `yaml:"collapseSlashes,omitempty"`
+	ShadowRegex               []string            `yaml:"shadowRegex,omitempty"`
+	Mode                      string              `yaml:"mode,omitempty"`
+	ReasonHeader              string              `yaml:"reasonHeader,omitempty"`
+	ReportAllMatches          bool                `yaml:"reportAllMatches,omitempty"`
+	BlockQueryKeys            []string            `yaml:"blockQueryKeys,omitempty"`
+	DecodeQueryValues         bool                `yaml:"decodeQueryValues,omitempty"`
+	DoubleDecodeQueryValues   bool                `yaml:"doubleDecodeQueryValues,omitempty"`
+	MatchEscapedFragment      bool                `yaml:"matchEscapedFragment,omitempty"`
+	TrackTopBlocked           bool                `yaml:"trackTopBlocked,omitempty"`
+	TrackTopBlockedIPs        bool                `yaml:"trackTopBlockedIPs,omitempty"`
+	TopBlockedMaxEntries      int                 `yaml:"topBlockedMaxEntries,omitempty"`
+	RecentBlocksCapacity      int                 `yaml:"recentBlocksCapacity,omitempty"`
+	AuditFile                 string              `yaml:"auditFile,omitempty"`
+	StatusPath                string              `yaml:"statusPath,omitempty"`
+	DebugEvalPath             string              `yaml:"debugEvalPath,omitempty"`
+	SilentStartUp             bool                `yaml:"silentStartUp"`
+	LogOutput                 string              `yaml:"logOutput,omitempty"`
+	AccessLog                 bool                `yaml:"accessLog,omitempty"`
+	TreatHeadAsGet            bool                `yaml:"treatHeadAsGet"`
+	NeverBlockRoot            bool                `yaml:"neverBlockRoot,omitempty"`
+	SkipExtensions            []string            `yaml:"skipExtensions,omitempty"`
+	Ports                     []int               `yaml:"ports,omitempty"`
+	Action                    string              `yaml:"action,omitempty"`
+	DecoyBody                 string              `yaml:"decoyBody,omitempty"`
+	DecoyContentType          string              `yaml:"decoyContentType,omitempty"`
+	RewritePath               string              `yaml:"rewritePath,omitempty"`
+	BlockBody                 string              `yaml:"blockBody,omitempty"`
+	BlockDelay                string              `yaml:"blockDelay,omitempty"`
+	BlockDelayJitter          string              `yaml:"blockDelayJitter,omitempty"`
+	UseHTTPError              bool                `yaml:"useHTTPError,omitempty"`
+	HTTPErrorMessage          string              `yaml:"httpErrorMessage,omitempty"`
+	EchoOnBlock               bool                `yaml:"echoOnBlock,omitempty"`
+	BlockContentType          string              `yaml:"blockContentType,omitempty"`
+	GzipBlockBody             bool                `yaml:"gzipBlockBody,omitempty"`
+	GzipMinBytes              int                 `yaml:"gzipMinBytes,omitempty"`
+	BodyTemplate              string              `yaml:"bodyTemplate,omitempty"`
+	BlockBodyJSON             string              `yaml:"blockBodyJSON,omitempty"`
+	BlockBodyHTML             string              `yaml:"blockBodyHTML,omitempty"`
+	PrefixResponses           []PrefixResponse    `yaml:"prefixResponses,omitempty"`
+	DefaultStatus             int                 `yaml:"defaultStatus,omitempty"`
+	StatusCode                int                 `yaml:"statusCode"`
 }
 
+// Modes of the middleware.
+const (
+	// modeBlock blocks matched requests.
+	modeBlock = "block"
+	// modeTag passes matched requests on, tagged with the reason header.
+	modeTag = "tag"
+)
+
+// Scopes of the url part the rules are matched against.
+const (
+	// matchScopeFull matches host, path and query, e.g. "localhost/wp-login?uid=1234".
+	matchScopeFull = "full"
+	// matchScopePath matches the path only, e.g. "/wp-login".
+	matchScopePath = "path"
+	// matchScopePathQuery matches path and query without the host, e.g. "/wp-login?uid=1234".
+	matchScopePathQuery = "pathquery"
+	// matchScopeHost matches the host only, e.g. "localhost".
+	matchScopeHost = "host"
+	// matchScopeHostPath matches the lowercased host without port and the path, e.g. "localhost/wp-login".
+	matchScopeHostPath = "hostpath"
+)
+
 /**********************************
  * Define traefik related methods *
  **********************************/
@@ -37,61 +395,1346 @@ type Config struct {
 // CreateConfig creates the default plugin configuration.
 func CreateConfig() *Config {
 	return &Config{
-		SilentStartUp: true,
-		StatusCode:    403, // https://cs.opensource.google/go/go/+/refs/tags/go1.21.4:src/net/http/status.go
+		Enabled:          true,
+		SilentStartUp:    true,
+		TreatHeadAsGet:   true,
+		IncludeIPv6ULA:   true,
+		IncludeLinkLocal: true,
+		Mode:             modeBlock,
+		MatchScope:       matchScopeFull,
+		MaxForwardedIPs:  defaultMaxForwardedIPs,
+		StatusCode:       403, // https://cs.opensource.google/go/go/+/refs/tags/go1.21.4:src/net/http/status.go
 	}
 }
 
 // New creates a new plugin.
 // Returns the configured BlockUrls plugin object.
 func New(ctx context.Context, next http.Handler, config *Config, name string) (http.Handler, error) {
+	return NewWithOptions(ctx, next, config, name)
+}
+
+// NewWithOptions creates a new plugin like New, customized with the given options.
+// This is meant for embedders running the middleware outside Traefik.
+func NewWithOptions(ctx context.Context, next http.Handler, config *Config, name string, options ...Option) (http.Handler, error) {
+	logger := newLogger(ctx, config.LogOutput, name)
 
 	if !config.SilentStartUp {
-		log.Println("Regex list: ", config.Regex)
-		log.Println("ExactMatch list: ", config.ExactMatch)
-		log.Println("StatusCode: ", config.StatusCode)
+		logger.Printf("Enabled: %v middleware=%s", config.Enabled, name)
+		logger.Printf("Regex list: %v middleware=%s", config.Regex, name)
+		logger.Printf("Rules: %v middleware=%s", config.Rules, name)
+		logger.Printf("ExactMatch list: %v middleware=%s", config.ExactMatch, name)
+		logger.Printf("Strings list: %v middleware=%s", config.Strings, name)
+		logger.Printf("StringsFile: %v middleware=%s", config.StringsFile, name)
+		logger.Printf("AllowLocalRequests: %v middleware=%s", config.AllowLocalRequests, name)
+		logger.Printf("MaxForwardedIPs: %v middleware=%s", config.MaxForwardedIPs, name)
+		logger.Printf("MatchScope: %v middleware=%s", config.MatchScope, name)
+		logger.Printf("Mode: %v middleware=%s", config.Mode, name)
+		logger.Printf("StatusCode: %v middleware=%s", config.StatusCode, name)
 	}
 
-	// regular expressions
-	regexps := make([]*regexp.Regexp, len(config.Regex))
+	// literal substrings, optionally extended from a file
+	var fileStrings []string
 
-	for index, regex := range config.Regex {
-		compiledRegex, compileError := regexp.Compile(regex)
+	if config.StringsFile != "" {
+		var readError error
+
+		fileStrings, readError = readPatternFile(config.StringsFile)
+		if readError != nil {
+			return nil, fmt.Errorf("error reading strings file %q: %w", config.StringsFile, readError)
+		}
+	}
+
+	regexList, ruleList, matchStrings, limitError := limitRules(config, fileStrings, logger, name)
+	if limitError != nil {
+		return nil, limitError
+	}
+
+	// the inline strings kept by maxRules, a reload of the strings file replaces the ones after them
+	inlineStrings := matchStrings[:min(len(config.Strings), len(matchStrings))]
+
+	// regular expressions, compiled on first use with lazyCompile
+	var lazyRegexps []*lazyRegex
+	if config.LazyCompile {
+		var parseError error
+
+		lazyRegexps, parseError = newLazyRegexps(regexList)
+		if parseError != nil {
+			return nil, parseError
+		}
+
+		regexList = nil
+	}
+
+	regexps, compileError := compileRegexList(regexList)
+	if compileError != nil {
+		return nil, compileError
+	}
+
+	headerScanRegexps, compileError := compileRegexList(config.HeaderScanRegex)
+	if compileError != nil {
+		return nil, compileError
+	}
+
+	trailerRegexps, compileError := compileRegexList(config.TrailerRegex)
+	if compileError != nil {
+		return nil, compileError
+	}
+
+	formFields, compileError := compileFormFields(config.FormFieldRegex)
+	if compileError != nil {
+		return nil, compileError
+	}
+
+	formMaxBytes := config.FormMaxBytes
+	if formMaxBytes <= 0 {
+		formMaxBytes = defaultFormMaxBytes
+	}
+
+	headerScanMaxBytes := config.HeaderScanMaxBytes
+	if headerScanMaxBytes <= 0 {
+		headerScanMaxBytes = defaultHeaderScanMaxBytes
+	}
+
+	// fingerprints
+	blockedFingerprints, fingerprintRegexps, compileError := compileFingerprints(config.BlockedFingerprints)
+	if compileError != nil {
+		return nil, compileError
+	}
+
+	// allow expressions
+	allowRegexps, compileError := compileRegexList(config.AllowRegex)
+	if compileError != nil {
+		return nil, compileError
+	}
+
+	// client certificate expressions
+	clientCertCNRegexps, compileError := compileRegexList(config.ClientCertCNRegex)
+	if compileError != nil {
+		return nil, compileError
+	}
+
+	allowClientCertCNRegexps, compileError := compileRegexList(config.AllowClientCertCNRegex)
+	if compileError != nil {
+		return nil, compileError
+	}
+
+	// composite expressions
+	compositeRegexps, compileError := compileRegexList(config.CompositeRegex)
+	if compileError != nil {
+		return nil, compileError
+	}
+
+	compositeFormat := config.CompositeFormat
+	if compositeFormat == "" {
+		compositeFormat = defaultCompositeFormat
+	}
+
+	// shadow expressions
+	shadowRegexps, compileError := compileRegexList(config.ShadowRegex)
+	if compileError != nil {
+		return nil, compileError
+	}
+
+	// header expressions
+	acceptRegexps, compileError := compileRegexList(config.AcceptRegex)
+	if compileError != nil {
+		return nil, compileError
+	}
+
+	acceptLanguageRegexps, compileError := compileRegexList(config.AcceptLanguageRegex)
+	if compileError != nil {
+		return nil, compileError
+	}
+
+	// rules with their own response settings
+	rules := make([]*rule, len(ruleList))
+
+	for index, configRule := range ruleList {
+		rewritePath := configRule.RewritePath
+		if rewritePath == "" {
+			rewritePath = config.RewritePath
+		}
+
+		if actionError := validateAction(configRule.Action, rewritePath); actionError != nil {
+			return nil, fmt.Errorf("error in rule %q: %w", configRule.Regex, actionError)
+		}
+
+		compiledRegex, compileError := regexp.Compile(configRule.Regex)
 		if compileError != nil {
-			return nil, fmt.Errorf("error compiling regex %q: %w", regex, compileError)
+			return nil, fmt.Errorf("error compiling rule regex %q: %w", configRule.Regex, compileError)
 		}
 
-		regexps[index] = compiledRegex
+		rate, rateError := newRuleRate(configRule)
+		if rateError != nil {
+			return nil, fmt.Errorf("error in rule %q: %w", configRule.Regex, rateError)
+		}
+
+		rules[index] = &rule{
+			regex:       compiledRegex,
+			statusCode:  configRule.StatusCode,
+			body:        configRule.Body,
+			contentType: configRule.ContentType,
+			silent:      configRule.Log != nil && !*configRule.Log,
+			action:      configRule.Action,
+			rewritePath: configRule.RewritePath,
+			methods:     configRule.Methods,
+			rate:        rate,
+		}
+	}
+
+	mode := config.Mode
+	if mode == "" {
+		mode = modeBlock
+	}
+
+	if mode != modeBlock && mode != modeTag {
+		return nil, fmt.Errorf("invalid mode %q, expected %q or %q", config.Mode, modeBlock, modeTag)
+	}
+
+	if actionError := validateAction(config.Action, config.RewritePath); actionError != nil {
+		return nil, actionError
+	}
+
+	catchAll, catchAllError := compileCatchAll(config.CatchAll, config.RewritePath)
+	if catchAllError != nil {
+		return nil, catchAllError
+	}
+
+	geoBlock, geoBlockError := compileGeoBlock(config.GeoBlock)
+	if geoBlockError != nil {
+		return nil, geoBlockError
+	}
+
+	if portsError := validatePorts(config.Ports); portsError != nil {
+		return nil, portsError
+	}
+
+	matchScope, scopeError := parseMatchScope(config.MatchScope)
+	if scopeError != nil {
+		return nil, scopeError
+	}
+
+	order, orderError := parseOrder(config.Order)
+	if orderError != nil {
+		return nil, orderError
+	}
+
+	stringMatchMode, modeError := parseStringMatchMode(config.StringMatchMode)
+	if modeError != nil {
+		return nil, modeError
+	}
+
+	ruleSets, compileError := compileRuleSets(config.RuleSets)
+	if compileError != nil {
+		return nil, compileError
+	}
+
+	allOfGroups, compileError := compileAllOf(config.AllOf)
+	if compileError != nil {
+		return nil, compileError
+	}
+
+	// block bodies negotiated by the Accept header, json first on equal quality
+	negotiatedTypes, negotiatedBodies := []string{}, map[string]string{}
+	if config.BlockBodyJSON != "" {
+		negotiatedTypes = append(negotiatedTypes, mediaTypeJSON)
+		negotiatedBodies[mediaTypeJSON] = config.BlockBodyJSON
+	}
+
+	if config.BlockBodyHTML != "" {
+		negotiatedTypes = append(negotiatedTypes, mediaTypeHTML)
+		negotiatedBodies[mediaTypeHTML] = config.BlockBodyHTML
+	}
+
+	blockDelay, blockDelayJitter, delayError := parseBlockDelay(config)
+	if delayError != nil {
+		return nil, delayError
+	}
+
+	prefixResponses, prefixError := compilePrefixResponses(config.PrefixResponses)
+	if prefixError != nil {
+		return nil, prefixError
+	}
+
+	// standalone use without a next handler, allowed requests get the default status
+	if next == nil {
+		defaultStatus := config.DefaultStatus
+		if defaultStatus == 0 {
+			defaultStatus = http.StatusOK
+		}
+
+		next = http.HandlerFunc(func(responseWriter http.ResponseWriter, _ *http.Request) {
+			responseWriter.WriteHeader(defaultStatus)
+		})
+	}
+
+	if config.AccessLog {
+		next = &accessLogHandler{next: next, logger: logger, name: name}
+	}
+
+	allowedIPs, parseError := parseIPNets(config.AllowedIPs)
+	if parseError != nil {
+		return nil, fmt.Errorf("error parsing allowedIPs: %w", parseError)
+	}
+
+	bypassIPs, parseError := parseIPNets(config.BypassIPs)
+	if parseError != nil {
+		return nil, fmt.Errorf("error parsing bypassIPs: %w", parseError)
 	}
 
-	return &traefik_block_regex_urls{
+	gzipMinBytes := config.GzipMinBytes
+	if gzipMinBytes <= 0 {
+		gzipMinBytes = defaultGzipMinBytes
+	}
+
+	mixedCaseMaxPercent := config.MixedCaseMaxPercent
+	if mixedCaseMaxPercent <= 0 {
+		mixedCaseMaxPercent = defaultMixedCaseMaxPercent
+	}
+
+	pathEntropyThreshold := config.PathEntropyThreshold
+	if pathEntropyThreshold <= 0 {
+		pathEntropyThreshold = defaultPathEntropyThreshold
+	}
+
+	pathEntropyMinLength := config.PathEntropyMinLength
+	if pathEntropyMinLength <= 0 {
+		pathEntropyMinLength = defaultPathEntropyMinLength
+	}
+
+	trustedIPHeader, trustedError := newTrustedIPHeader(config)
+	if trustedError != nil {
+		return nil, trustedError
+	}
+
+	maxForwardedIPs := config.MaxForwardedIPs
+	if maxForwardedIPs <= 0 {
+		maxForwardedIPs = defaultMaxForwardedIPs
+	}
+
+	blockUrls := &traefik_block_regex_urls{
 		next:          next,
 		name:          name,
+		enabled:       config.Enabled,
 		regexps:       regexps,
+		rules:         rules,
+		ruleSets:      ruleSets,
+		allOf:         allOfGroups,
 		exactMatch:    config.ExactMatch,
 		silentStartUp: config.SilentStartUp,
+		logger:        logger,
 		statusCode:    config.StatusCode,
-	}, nil
+
+		treatHeadAsGet: config.TreatHeadAsGet,
+		neverBlockRoot: config.NeverBlockRoot,
+		skipExtensions: normalizeExtensions(config.SkipExtensions),
+		ports:          config.Ports,
+
+		blockBody:        config.BlockBody,
+		blockContentType: config.BlockContentType,
+		useHTTPError:     config.UseHTTPError,
+		httpErrorMessage: config.HTTPErrorMessage,
+		echoOnBlock:      config.EchoOnBlock,
+		gzipBlockBody:    config.GzipBlockBody,
+		gzipMinBytes:     gzipMinBytes,
+		bodyTemplate:     config.BodyTemplate,
+		negotiatedTypes:  negotiatedTypes,
+		negotiatedBodies: negotiatedBodies,
+		prefixResponses:  prefixResponses,
+		action:           config.Action,
+		decoyBody:        config.DecoyBody,
+		decoyContentType: config.DecoyContentType,
+		rewritePath:      config.RewritePath,
+		matchStrings:     matchStrings,
+		staticPatterns:   fixedPatternCount(config) + len(regexList) + len(lazyRegexps) + len(ruleList) + len(inlineStrings),
+		fileStrings:      len(matchStrings) - len(inlineStrings),
+
+		decodeQueryValues:       config.DecodeQueryValues,
+		doubleDecodeQueryValues: config.DoubleDecodeQueryValues,
+		matchEscapedFragment:    config.MatchEscapedFragment,
+		blockQueryKeys:          config.BlockQueryKeys,
+
+		allowLocalRequests: config.AllowLocalRequests,
+		allowedIPs:         allowedIPs,
+		bypassIPs:          bypassIPs,
+		auditAllowlisted:   config.AuditAllowlistedMatches,
+		allowRegexps:       allowRegexps,
+		allowQueryStrings:  config.AllowQueryStrings,
+		defaultDeny:        config.DefaultDeny,
+		catchAll:           catchAll,
+		order:              order,
+
+		skipIfAuthenticated: config.SkipIfAuthenticated,
+		sessionCookie:       config.SessionCookie,
+		maxForwardedIPs:     maxForwardedIPs,
+		forwardedIPDepth:    config.ForwardedIPDepth,
+		trustedIPHeader:     trustedIPHeader,
+		privateIPBlocks:     InitializePrivateIPBlocksWith(PrivateRanges{IncludeIPv6ULA: config.IncludeIPv6ULA, IncludeLinkLocal: config.IncludeLinkLocal}),
+
+		acceptRegexps:         acceptRegexps,
+		acceptLanguageRegexps: acceptLanguageRegexps,
+		blockMissingAccept:    config.BlockMissingAccept,
+
+		scanAllHeaders:     config.ScanAllHeaders,
+		scanHeaders:        config.ScanHeaders,
+		scanHeadersExclude: config.ScanHeadersExclude,
+		headerScanRegexps:  headerScanRegexps,
+		trailerRegexps:     trailerRegexps,
+
+		formFields:         formFields,
+		formMaxBytes:       formMaxBytes,
+		claimBlocks:        newClaimBlocks(config.ClaimBlock),
+		geoBlock:           geoBlock,
+		headerScanMaxBytes: headerScanMaxBytes,
+
+		fingerprintHeader:   config.FingerprintHeader,
+		blockedFingerprints: blockedFingerprints,
+		fingerprintRegexps:  fingerprintRegexps,
+
+		clientCertCNRegexps:      clientCertCNRegexps,
+		allowClientCertCNRegexps: allowClientCertCNRegexps,
+
+		blockControlChars:        config.BlockControlChars,
+		blockInvalidUTF8Path:     config.BlockInvalidUTF8Path,
+		blockSmugglingIndicators: config.BlockSmugglingIndicators,
+		blockSNIHostMismatch:     config.BlockSNIHostMismatch,
+		expectedHosts:            config.ExpectedHosts,
+		blockedTLDs:              normalizeTLDs(config.BlockedTLDs),
+		expectHTTPS:              config.ExpectHTTPS,
+		expectHTTPSRedirect:      config.ExpectHTTPSRedirect,
+		blockMixedCasePath:       config.BlockMixedCasePath,
+		mixedCaseMaxPercent:      mixedCaseMaxPercent,
+		maxTraversalDepth:        config.MaxTraversalDepth,
+		blockHighEntropyPath:     config.BlockHighEntropyPath,
+		pathEntropyThreshold:     pathEntropyThreshold,
+		pathEntropyMinLength:     pathEntropyMinLength,
+
+		compositeFormat:  compositeFormat,
+		compositeRegexps: compositeRegexps,
+
+		counters: newDecisionCounters(),
+
+		mode:             mode,
+		reasonHeader:     config.ReasonHeader,
+		reportAllMatches: config.ReportAllMatches,
+		matchScope:       matchScope,
+
+		trimLeadingSlash: config.TrimLeadingSlash,
+		includeFragment:  config.IncludeFragment,
+		normalizeHost:    config.NormalizeHost,
+		collapseSlashes:  config.CollapseSlashes,
+
+		combineRegex:             config.CombineRegex,
+		includeStringsInCombined: config.IncludeStringsInCombined,
+		disableStringMatch:       config.DisableStringMatch,
+		stringMatchMode:          stringMatchMode,
+		ahoCorasick:              config.AhoCorasick,
+		maxRules:                 config.MaxRules,
+		maxRulesAction:           config.MaxRulesAction,
+		lazyRegexps:              lazyRegexps,
+
+		statusPath:    config.StatusPath,
+		debugEvalPath: config.DebugEvalPath,
+
+		blockDelay:       blockDelay,
+		blockDelayJitter: blockDelayJitter,
+
+		now:        time.Now,
+		randInt63n: rand.Int63n,
+	}
+
+	if config.GraceFirstRequest {
+		graceTTL := defaultGraceTTL
+		if config.GraceTTL != "" {
+			parsedTTL, parseError := time.ParseDuration(config.GraceTTL)
+			if parseError != nil || parsedTTL <= 0 {
+				return nil, fmt.Errorf("invalid graceTTL %q", config.GraceTTL)
+			}
+
+			graceTTL = parsedTTL
+		}
+
+		graceMaxEntries := config.GraceMaxEntries
+		if graceMaxEntries <= 0 {
+			graceMaxEntries = defaultGraceMaxEntries
+		}
+
+		blockUrls.graceTracker = newGraceTracker(graceTTL, graceMaxEntries)
+
+		if blockUrls.forwardedIPDepth <= 0 && blockUrls.trustedIPHeader == nil {
+			// logged even on a silent start up, a spoofed client ip gets a free pass per request
+			blockUrls.logger.Printf("Warning: graceFirstRequest without forwardedIPDepth or trustedIPHeader trusts the client controlled X-Forwarded-For: middleware=%s", name)
+		}
+	}
+
+	if config.MinInterval != "" {
+		minInterval, parseError := time.ParseDuration(config.MinInterval)
+		if parseError != nil || minInterval <= 0 {
+			return nil, fmt.Errorf("invalid minInterval %q", config.MinInterval)
+		}
+
+		maxEntries := config.MinIntervalMaxEntries
+		if maxEntries <= 0 {
+			maxEntries = defaultMinIntervalMaxEntries
+		}
+
+		blockUrls.intervalTracker = newIntervalTracker(minInterval, maxEntries)
+	}
+
+	if config.DistinctURLThreshold > 0 {
+		window := defaultDistinctURLWindow
+		if config.DistinctURLWindow != "" {
+			parsedWindow, parseError := time.ParseDuration(config.DistinctURLWindow)
+			if parseError != nil || parsedWindow <= 0 {
+				return nil, fmt.Errorf("invalid distinctURLWindow %q", config.DistinctURLWindow)
+			}
+
+			window = parsedWindow
+		}
+
+		maxEntries := config.DistinctURLMaxEntries
+		if maxEntries <= 0 {
+			maxEntries = defaultDistinctURLMaxEntries
+		}
+
+		blockUrls.distinctURLTracker = newDistinctURLTracker(config.DistinctURLThreshold, window, maxEntries)
+	}
+
+	if config.StartupGrace != "" {
+		startupGrace, parseError := time.ParseDuration(config.StartupGrace)
+		if parseError != nil || startupGrace < 0 {
+			return nil, fmt.Errorf("invalid startupGrace %q", config.StartupGrace)
+		}
+
+		blockUrls.startupGrace = startupGrace
+	}
+
+	if len(shadowRegexps) > 0 {
+		blockUrls.shadowRules = newShadowRules(shadowRegexps)
+	}
+
+	topBlockedMaxEntries := config.TopBlockedMaxEntries
+	if topBlockedMaxEntries <= 0 {
+		topBlockedMaxEntries = defaultTopBlockedMaxEntries
+	}
+
+	if config.TrackTopBlocked {
+		blockUrls.topBlocked = newTopCounter(topBlockedMaxEntries)
+	}
+
+	if config.TrackTopBlockedIPs {
+		blockUrls.topBlockedIPs = newTopCounter(topBlockedMaxEntries)
+	}
+
+	if config.RecentBlocksCapacity > 0 {
+		blockUrls.recentBlocks = newRecentBlocks(config.RecentBlocksCapacity)
+	}
+
+	if config.MaxConcurrent > 0 {
+		wait := defaultMaxConcurrentWait
+		if config.MaxConcurrentWait != "" {
+			parsedWait, parseError := time.ParseDuration(config.MaxConcurrentWait)
+			if parseError != nil || parsedWait < 0 {
+				return nil, fmt.Errorf("invalid maxConcurrentWait %q", config.MaxConcurrentWait)
+			}
+
+			wait = parsedWait
+		}
+
+		statusCode := config.MaxConcurrentStatusCode
+		if statusCode == 0 {
+			statusCode = http.StatusServiceUnavailable
+		}
+
+		blockUrls.concurrencyLimit = newConcurrencyLimit(config.MaxConcurrent, wait, statusCode)
+	}
+
+	if config.RulesDir != "" {
+		blockUrls.hostRules = newHostRules(config.RulesDir, name, logger, blockUrls.fitHostRules)
+	}
+
+	if config.AuditFile != "" {
+		blockUrls.auditLog = openAuditLog(ctx, config.AuditFile, name, logger)
+	}
+
+	if config.ActiveFrom != "" || config.ActiveTo != "" {
+		activeWindow, windowError := newTimeWindow(config.ActiveFrom, config.ActiveTo, config.ActiveTimezone)
+		if windowError != nil {
+			return nil, windowError
+		}
+
+		blockUrls.activeWindow = activeWindow
+	}
+
+	if len(config.VerifiedBots) > 0 {
+		// the leftmost X-Forwarded-For entry can name a real bot ip, which would pass the reverse DNS check
+		if blockUrls.forwardedIPDepth <= 0 && blockUrls.trustedIPHeader == nil {
+			return nil, fmt.Errorf("verifiedBots requires forwardedIPDepth or trustedIPHeader, the client controls X-Forwarded-For")
+		}
+
+		cacheTTL := defaultVerifiedBotsCacheTTL
+		if config.VerifiedBotsCacheTTL != "" {
+			parsedTTL, parseError := time.ParseDuration(config.VerifiedBotsCacheTTL)
+			if parseError != nil || parsedTTL <= 0 {
+				return nil, fmt.Errorf("invalid verifiedBotsCacheTTL %q", config.VerifiedBotsCacheTTL)
+			}
+
+			cacheTTL = parsedTTL
+		}
+
+		maxEntries := config.VerifiedBotsMaxEntries
+		if maxEntries <= 0 {
+			maxEntries = defaultVerifiedBotsMaxEntries
+		}
+
+		blockUrls.botVerifier = newBotVerifier(config.VerifiedBots, cacheTTL, maxEntries, name, logger)
+	}
+
+	for _, option := range options {
+		option(blockUrls)
+	}
+
+	blockUrls.startedAt = blockUrls.now()
+
+	if config.DenyFeedURL != "" {
+		refreshInterval := defaultDenyFeedRefreshInterval
+		if config.DenyFeedRefreshInterval != "" {
+			parsedInterval, parseError := time.ParseDuration(config.DenyFeedRefreshInterval)
+			if parseError != nil || parsedInterval <= 0 {
+				return nil, fmt.Errorf("invalid denyFeedRefreshInterval %q", config.DenyFeedRefreshInterval)
+			}
+
+			refreshInterval = parsedInterval
+		}
+
+		go blockUrls.watchDenyFeed(ctx, config.DenyFeedURL, refreshInterval)
+	}
+
+	if config.CombineRegex {
+		combined, combineError := blockUrls.buildCombined(regexps, matchStrings)
+		if combineError != nil {
+			return nil, fmt.Errorf("error combining regex: %w", combineError)
+		}
+
+		blockUrls.combined = combined
+	}
+
+	blockUrls.automaton = blockUrls.buildAutomaton(matchStrings)
+
+	if config.StringsFile != "" && config.StringsFileReloadInterval != "" {
+		interval, parseError := time.ParseDuration(config.StringsFileReloadInterval)
+		if parseError != nil || interval <= 0 {
+			return nil, fmt.Errorf("invalid stringsFileReloadInterval %q", config.StringsFileReloadInterval)
+		}
+
+		watchPatternFile(ctx, config.StringsFile, interval, name, logger, func(fileStrings []string) {
+			blockUrls.mu.Lock()
+
+			loaded := len(fileStrings)
+
+			kept, limitError := blockUrls.fitMaxRules(loaded, blockUrls.loadedPatterns()-blockUrls.fileStrings)
+			if limitError != nil {
+				blockUrls.mu.Unlock()
+				blockUrls.logger.Printf("error reloading strings file %q, keeping the previous strings: %v middleware=%s", config.StringsFile, limitError, name)
+
+				return
+			}
+
+			matchStrings := append(slices.Clone(inlineStrings), fileStrings[:kept]...)
+
+			blockUrls.matchStrings = matchStrings
+			blockUrls.fileStrings = kept
+			blockUrls.automaton = blockUrls.buildAutomaton(matchStrings)
+			if blockUrls.combineRegex {
+				// literals always compile, the regexps compiled before
+				blockUrls.combined, _ = blockUrls.buildCombined(blockUrls.regexps, matchStrings)
+			}
+			blockUrls.mu.Unlock()
+
+			if kept < loaded {
+				blockUrls.logger.Printf("Truncated strings file %q from %d to %d entries to fit maxRules %d: middleware=%s", config.StringsFile, loaded, kept, blockUrls.maxRules, name)
+			}
+
+			blockUrls.logger.Printf("Reloaded strings file %q (%d entries): middleware=%s", config.StringsFile, kept, name)
+		})
+	}
+
+	if blockUrls.statusPath != "" && len(blockUrls.allowedIPs) == 0 {
+		// logged even on a silent start up, the status lists every pattern and the recent blocks
+		blockUrls.logger.Printf("Warning: statusPath without allowedIPs is disabled: middleware=%s", name)
+		blockUrls.statusPath = ""
+	}
+
+	if blockUrls.debugEvalPath != "" && len(blockUrls.allowedIPs) == 0 {
+		// logged even on a silent start up, the endpoint lets its clients probe the rules for gaps
+		blockUrls.logger.Printf("Warning: debugEvalPath without allowedIPs is disabled: middleware=%s", name)
+		blockUrls.debugEvalPath = ""
+	}
+
+	if config.EchoOnBlock {
+		// logged even on a silent start up, the echo must not go unnoticed in production
+		blockUrls.logger.Printf("Warning: echoOnBlock exposes request details in block responses, meant for non-production use only: middleware=%s", name)
+	}
+
+	if !config.SilentStartUp {
+		blockUrls.logger.Printf("Loaded %s: middleware=%s", blockUrls.summary(), name)
+	}
+
+	return blockUrls, nil
 }
 
 // This method is the middleware called during runtime and handling middleware actions.
 func (blockUrls *traefik_block_regex_urls) ServeHTTP(responseWriter http.ResponseWriter, request *http.Request) {
 
-	fullUrl := request.Host + request.URL.RequestURI()
+	// only this middleware sets the reason header, a client sent one would reach the backend and the access log as is
+	if blockUrls.reasonHeader != "" {
+		request.Header.Del(blockUrls.reasonHeader)
+	}
 
-	if slices.Contains(blockUrls.exactMatch, fullUrl) {
-		log.Printf("URL is blocked (exact match): (%s): middleware=%s", fullUrl, blockUrls.name)
-		responseWriter.WriteHeader(blockUrls.statusCode)
+	if !blockUrls.enabled {
+		blockUrls.next.ServeHTTP(responseWriter, request)
 		return
 	}
 
-	for _, regex := range blockUrls.regexps {
-		if regex.MatchString(fullUrl) {
-			log.Printf("URL is blocked (regex match): (%s) middleware=%s", fullUrl, blockUrls.name)
-			responseWriter.WriteHeader(blockUrls.statusCode)
-			return
+	client := blockUrls.newRequestClient(request)
+
+	if blockUrls.statusPath != "" && request.URL.Path == blockUrls.statusPath && blockUrls.canSeeStatus(client) {
+		blockUrls.serveStatus(responseWriter)
+		return
+	}
+
+	if blockUrls.debugEvalPath != "" && request.URL.Path == blockUrls.debugEvalPath && blockUrls.canSeeStatus(client) {
+		blockUrls.serveDebugEval(responseWriter, request)
+		return
+	}
+
+	// safety valve against a broad rule taking down the whole site
+	if blockUrls.neverBlockRoot && request.URL.Path == "/" {
+		blockUrls.next.ServeHTTP(responseWriter, request)
+		return
+	}
+
+	if !blockUrls.inPortScope(request) {
+		blockUrls.next.ServeHTTP(responseWriter, request)
+		return
+	}
+
+	if len(blockUrls.skipExtensions) > 0 && slices.Contains(blockUrls.skipExtensions, strings.ToLower(path.Ext(request.URL.Path))) {
+		blockUrls.next.ServeHTTP(responseWriter, request)
+		return
+	}
+
+	if blockUrls.startupGrace > 0 && blockUrls.inStartupGrace() {
+		blockUrls.next.ServeHTTP(responseWriter, request)
+		return
+	}
+
+	if blockUrls.activeWindow != nil && !blockUrls.activeWindow.contains(blockUrls.now()) {
+		blockUrls.next.ServeHTTP(responseWriter, request)
+		return
+	}
+
+	if blockUrls.allowLocalRequests && blockUrls.isLocalRequest(client) {
+		blockUrls.next.ServeHTTP(responseWriter, request)
+		return
+	}
+
+	// with order allow,deny the allow rules do not pass a request on, they only spare it the default deny
+	allowDeny := blockUrls.order == orderAllowDeny
+	allowed := false
+
+	if blockUrls.isBypassedRequest(client) {
+		if !allowDeny {
+			if blockUrls.auditAllowlisted {
+				blockUrls.auditAllowlistedMatch(request, client)
+			}
+
+			blockUrls.next.ServeHTTP(responseWriter, request)
+			return
+		}
+
+		allowed = true
+	}
+
+	if matchClientCertCN(blockUrls.allowClientCertCNRegexps, request) != nil {
+		blockUrls.next.ServeHTTP(responseWriter, request)
+		return
+	}
+
+	if blockUrls.matchAllowRules(request) {
+		if !allowDeny {
+			blockUrls.next.ServeHTTP(responseWriter, request)
+			return
+		}
+
+		allowed = true
+	}
+
+	if blockUrls.skipIfAuthenticated && blockUrls.isAuthenticated(request) {
+		blockUrls.next.ServeHTTP(responseWriter, request)
+		return
+	}
+
+	if blockUrls.expectHTTPS && blockUrls.expectHTTPSRedirect && requestScheme(request) == "http" {
+		redirectToHTTPS(responseWriter, request)
+		return
+	}
+
+	blockMatch, evaluated := blockUrls.evaluateLimited(request, client)
+	if !evaluated {
+		blockUrls.logger.Printf("Request is shed (max concurrent evaluations reached): (%s) middleware=%s", fullURL(request), blockUrls.name)
+		writeResponse(responseWriter, blockUrls.concurrencyLimit.statusCode, "", "")
+		return
+	}
+
+	if blockMatch == nil && blockUrls.catchAll != nil && !allowed {
+		blockMatch = &match{reason: "catch-all", url: fullURL(request), rule: blockUrls.catchAll}
+	}
+
+	if blockMatch == nil && blockUrls.defaultDeny && !allowed {
+		blockMatch = &match{reason: "default deny", url: fullURL(request)}
+	}
+
+	if blockMatch == nil && allowDeny && !allowed {
+		blockMatch = &match{reason: "not allowed", url: fullURL(request)}
+	}
+
+	if blockMatch == nil {
+		blockUrls.next.ServeHTTP(responseWriter, request)
+		return
+	}
+
+	if blockUrls.botVerifier != nil && blockUrls.botVerifier.isVerifiedBot(request.UserAgent(), client.clientIP(), blockUrls.now()) {
+		blockUrls.logger.Printf("URL is allowed (verified bot, %s): (%s) middleware=%s", blockMatch.reason, blockMatch.url, blockUrls.name)
+		blockUrls.next.ServeHTTP(responseWriter, blockUrls.decide(request, DecisionAllowed, blockMatch))
+		return
+	}
+
+	if blockUrls.mode == modeTag {
+		blockUrls.logger.Printf("URL is tagged (%s): (%s) middleware=%s", blockMatch.reason, blockMatch.url, blockUrls.name)
+
+		if blockUrls.reportAllMatches {
+			if patterns := blockUrls.allMatches(request); len(patterns) > 0 {
+				responseWriter.Header().Set(matchedRulesHeader, strings.Join(patterns, ","))
+			}
+		}
+
+		blockUrls.allowTagged(responseWriter, blockUrls.decide(request, DecisionTagged, blockMatch), blockMatch)
+		return
+	}
+
+	if blockUrls.graceTracker != nil && blockUrls.graceTracker.grant(client.clientIP(), blockUrls.now()) {
+		blockUrls.logger.Printf("URL is allowed (first request grace, %s): (%s) middleware=%s", blockMatch.reason, blockMatch.url, blockUrls.name)
+		blockUrls.allowTagged(responseWriter, blockUrls.decide(request, DecisionAllowed, blockMatch), blockMatch)
+		return
+	}
+
+	blockUrls.block(responseWriter, request, client, blockMatch)
+}
+
+// allowTagged passes a matched request on, carrying the matched pattern in the reason header if configured.
+func (blockUrls *traefik_block_regex_urls) allowTagged(responseWriter http.ResponseWriter, request *http.Request, blockMatch *match) {
+	if blockUrls.reasonHeader != "" {
+		reason := blockMatch.pattern
+		if reason == "" {
+			reason = blockMatch.reason
 		}
+
+		request.Header.Set(blockUrls.reasonHeader, reason)
 	}
 
 	blockUrls.next.ServeHTTP(responseWriter, request)
 }
+
+// isAuthenticated reports whether the request carries an Authorization header or the session cookie.
+// Credentials are not verified, this is left to the backend.
+func (blockUrls *traefik_block_regex_urls) isAuthenticated(request *http.Request) bool {
+	if request.Header.Get("Authorization") != "" {
+		return true
+	}
+
+	if blockUrls.sessionCookie == "" {
+		return false
+	}
+
+	cookie, cookieError := request.Cookie(blockUrls.sessionCookie)
+
+	return cookieError == nil && cookie.Value != ""
+}
+
+// evaluation selects the checks and side effects of an evaluation, the zero value is the one of served requests.
+type evaluation struct {
+	// dryRun leaves the rule rates, the minInterval and distinctURLThreshold trackers and the host rules cache
+	// untouched, so evaluating a request does not count it.
+	dryRun bool
+	// urlOnly skips the checks of request headers, which a bare url has none of.
+	urlOnly bool
+	// client is the client ip of the request if already resolved for other checks, resolved on first use otherwise.
+	client *requestClient
+}
+
+// evaluate runs the request against the configured rules.
+// Returns the first match, or nil if the request is not blocked.
+func (blockUrls *traefik_block_regex_urls) evaluate(request *http.Request, client *requestClient) *match {
+	return blockUrls.evaluateWith(request, evaluation{client: client})
+}
+
+// evaluateWith runs the request against the configured rules, with the checks and side effects of the evaluation.
+func (blockUrls *traefik_block_regex_urls) evaluateWith(request *http.Request, eval evaluation) *match {
+	client := eval.client
+	if client == nil {
+		client = blockUrls.newRequestClient(request)
+	}
+
+	if reason := blockUrls.matchHeaders(request); reason != "" && !eval.urlOnly {
+		return &match{reason: reason, url: fullURL(request)}
+	}
+
+	if len(blockUrls.blockQueryKeys) > 0 && request.URL.RawQuery != "" {
+		query := request.URL.Query()
+
+		for _, key := range blockUrls.blockQueryKeys {
+			if query.Has(key) {
+				return &match{reason: "query key match", url: fullURL(request), pattern: key}
+			}
+		}
+	}
+
+	if pattern := blockUrls.matchHeaderScan(request); pattern != "" && !eval.urlOnly {
+		return &match{reason: "header scan match", url: fullURL(request), pattern: pattern}
+	}
+
+	if regex := matchTrailers(blockUrls.trailerRegexps, request); regex != nil {
+		return &match{reason: "trailer match", url: fullURL(request), pattern: regex.String()}
+	}
+
+	if blockMatch := blockUrls.matchFormFields(request); blockMatch != nil {
+		return blockMatch
+	}
+
+	if blockMatch := blockUrls.matchGeoBlock(request); blockMatch != nil && !eval.urlOnly {
+		return blockMatch
+	}
+
+	if pattern := blockUrls.matchClaims(request); pattern != "" && !eval.urlOnly {
+		return &match{reason: "jwt claim match", url: fullURL(request), pattern: pattern}
+	}
+
+	if pattern := blockUrls.matchFingerprint(request); pattern != "" && !eval.urlOnly {
+		return &match{reason: "fingerprint match", url: fullURL(request), pattern: pattern}
+	}
+
+	if regex := matchClientCertCN(blockUrls.clientCertCNRegexps, request); regex != nil {
+		return &match{reason: "client certificate match", url: fullURL(request), pattern: regex.String()}
+	}
+
+	if blockUrls.isDeniedByFeed(client) {
+		return &match{reason: "deny feed ip", url: fullURL(request)}
+	}
+
+	if blockUrls.intervalTracker != nil && !eval.dryRun && blockUrls.intervalTracker.tooFast(repeatKey(client), blockUrls.now()) {
+		return &match{reason: "too fast repeat", url: fullURL(request)}
+	}
+
+	if blockUrls.distinctURLTracker != nil && !eval.dryRun && blockUrls.distinctURLTracker.exceeded(client.clientIP(), request.URL.Path, blockUrls.now()) {
+		return &match{reason: "too many distinct urls", url: fullURL(request)}
+	}
+
+	if reason := blockUrls.matchRequestAnomalies(request); reason != "" {
+		return &match{reason: reason, url: fullURL(request)}
+	}
+
+	if len(blockUrls.compositeRegexps) > 0 {
+		composite := renderComposite(blockUrls.compositeFormat, request)
+
+		for _, regex := range blockUrls.compositeRegexps {
+			if regex.MatchString(composite) {
+				return &match{reason: "composite match", url: fullURL(request), pattern: regex.String()}
+			}
+		}
+	}
+
+	if reason := blockUrls.matchCustom(request); reason != "" {
+		return &match{reason: reason, url: fullURL(request)}
+	}
+
+	// the combined expression and the decoded query values need every regex value
+	if blockUrls.combineRegex || blockUrls.decodeQueryValues {
+		blockUrls.compileLazyRegex()
+	}
+
+	blockUrls.mu.RLock()
+	regexps := blockUrls.regexps
+	lazyRegexps := blockUrls.lazyRegexps
+	matchStrings := blockUrls.matchStrings
+	automaton := blockUrls.automaton
+	combined := blockUrls.combined
+	blockUrls.mu.RUnlock()
+
+	if blockUrls.disableStringMatch {
+		matchStrings = nil
+		automaton = nil
+	}
+
+	// fast path: without any rule there is no need to build the match target
+	if len(blockUrls.exactMatch) == 0 && len(matchStrings) == 0 && len(regexps) == 0 && len(lazyRegexps) == 0 && len(blockUrls.rules) == 0 && len(blockUrls.ruleSets) == 0 && len(blockUrls.allOf) == 0 && blockUrls.hostRules == nil {
+		return nil
+	}
+
+	target := blockUrls.matchTarget(request)
+
+	if slices.Contains(blockUrls.exactMatch, target) {
+		return &match{reason: "exact match", url: fullURL(request), pattern: target}
+	}
+
+	if automaton != nil {
+		if matchString, found := automaton.find(target); found {
+			return &match{reason: "string match", url: fullURL(request), pattern: matchString}
+		}
+	} else if combined == nil || !blockUrls.includeStringsInCombined {
+		for _, matchString := range matchStrings {
+			if blockUrls.matchesString(target, matchString) {
+				return &match{reason: "string match", url: fullURL(request), pattern: matchString}
+			}
+		}
+	}
+
+	if combined != nil {
+		if combined.MatchString(target) {
+			return blockUrls.identifyCombinedMatch(request, target, regexps, matchStrings)
+		}
+	} else {
+		// each lazy value is compiled when first tested, a request matching early compiles only the ones before
+		for _, lazy := range lazyRegexps {
+			if regex := blockUrls.compiled(lazy); regex != nil && regex.MatchString(target) {
+				return &match{reason: "regex match", url: fullURL(request), pattern: regex.String()}
+			}
+		}
+
+		for _, regex := range regexps {
+			if regex.MatchString(target) {
+				return &match{reason: "regex match", url: fullURL(request), pattern: regex.String()}
+			}
+		}
+	}
+
+	for _, matchedRule := range blockUrls.rules {
+		if !blockUrls.appliesToMethod(matchedRule, request.Method) {
+			continue
+		}
+
+		if matchedRule.regex.MatchString(target) {
+			// below its rate limit, a matching rule lets the request pass
+			if matchedRule.rate != nil && !matchedRule.rate.exceeded(blockUrls.now(), !eval.dryRun) {
+				continue
+			}
+
+			return &match{reason: "rule match", url: fullURL(request), pattern: matchedRule.regex.String(), rule: matchedRule}
+		}
+	}
+
+	if blockMatch := blockUrls.matchRuleSets(request); blockMatch != nil {
+		return blockMatch
+	}
+
+	if blockMatch := blockUrls.matchAllOf(request, target); blockMatch != nil {
+		return blockMatch
+	}
+
+	if blockMatch := blockUrls.matchHostRules(request, target, !eval.dryRun); blockMatch != nil {
+		return blockMatch
+	}
+
+	if blockUrls.decodeQueryValues {
+		if regex := blockUrls.matchQueryValues(request, regexps); regex != nil {
+			return &match{reason: "query value regex match", url: fullURL(request), pattern: regex.String()}
+		}
+	}
+
+	if blockUrls.matchEscapedFragment {
+		if regex := matchEscapedFragment(request, regexps); regex != nil {
+			return &match{reason: "escaped fragment match", url: fullURL(request), pattern: regex.String()}
+		}
+	}
+
+	return nil
+}
+
+// appliesToMethod reports whether the rule applies to the request method.
+// With treatHeadAsGet, rules scoped to GET also apply to HEAD, which scanners use to probe quietly.
+func (blockUrls *traefik_block_regex_urls) appliesToMethod(matchedRule *rule, method string) bool {
+	if len(matchedRule.methods) == 0 {
+		return true
+	}
+
+	for _, ruleMethod := range matchedRule.methods {
+		if strings.EqualFold(ruleMethod, method) {
+			return true
+		}
+
+		if blockUrls.treatHeadAsGet && method == http.MethodHead && strings.EqualFold(ruleMethod, http.MethodGet) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// matchTarget returns the part of the request url the rules are matched against, according to the match scope.
+func (blockUrls *traefik_block_regex_urls) matchTarget(request *http.Request) string {
+	return blockUrls.scopedTarget(request, blockUrls.matchScope)
+}
+
+// scopedTarget returns the part of the request url selected by the scope.
+// The fragment is excluded unless includeFragment is set.
+func (blockUrls *traefik_block_regex_urls) scopedTarget(request *http.Request, matchScope string) string {
+	var target string
+
+	switch matchScope {
+	case matchScopePath:
+		target = blockUrls.trimSlash(blockUrls.collapse(request.URL.Path))
+	case matchScopePathQuery:
+		target = blockUrls.trimSlash(blockUrls.collapse(request.URL.RequestURI()))
+	case matchScopeHost:
+		return blockUrls.matchHost(request)
+	case matchScopeHostPath:
+		target = strings.ToLower(hostname(blockUrls.matchHost(request)) + blockUrls.collapse(request.URL.Path))
+	default:
+		target = blockUrls.matchHost(request) + blockUrls.collapse(request.URL.RequestURI())
+	}
+
+	if blockUrls.includeFragment && request.URL.Fragment != "" {
+		target += "#" + request.URL.EscapedFragment()
+	}
+
+	return target
+}
+
+// matchHost returns the request host the rules are matched against, in ASCII form if normalizeHost is set.
+func (blockUrls *traefik_block_regex_urls) matchHost(request *http.Request) string {
+	if blockUrls.normalizeHost {
+		return asciiHost(request.Host)
+	}
+
+	return request.Host
+}
+
+// collapse replaces runs of slashes in the path part of a target with a single slash if collapseSlashes is set,
+// e.g. "//wp-login?next=//home" as "/wp-login?next=//home". The request itself is passed on unchanged.
+func (blockUrls *traefik_block_regex_urls) collapse(target string) string {
+	if !blockUrls.collapseSlashes {
+		return target
+	}
+
+	path, query, hasQuery := strings.Cut(target, "?")
+	if !strings.Contains(path, "//") {
+		return target
+	}
+
+	var collapsed strings.Builder
+
+	collapsed.Grow(len(target))
+
+	for index := 0; index < len(path); index++ {
+		if path[index] == '/' && index > 0 && path[index-1] == '/' {
+			continue
+		}
+
+		collapsed.WriteByte(path[index])
+	}
+
+	if hasQuery {
+		collapsed.WriteByte('?')
+		collapsed.WriteString(query)
+	}
+
+	return collapsed.String()
+}
+
+// trimSlash strips the leading slash of a path scoped target if trimLeadingSlash is set.
+func (blockUrls *traefik_block_regex_urls) trimSlash(target string) string {
+	if blockUrls.trimLeadingSlash {
+		return strings.TrimPrefix(target, "/")
+	}
+
+	return target
+}
+
+// normalizeExtensions lowercases the extensions and adds the leading dot where missing, e.g. "CSS" as ".css".
+func normalizeExtensions(extensions []string) []string {
+	normalized := make([]string, 0, len(extensions))
+
+	for _, extension := range extensions {
+		if extension == "" {
+			continue
+		}
+
+		if !strings.HasPrefix(extension, ".") {
+			extension = "." + extension
+		}
+
+		normalized = append(normalized, strings.ToLower(extension))
+	}
+
+	return normalized
+}
+
+// hostname returns the host without port.
+func hostname(host string) string {
+	if name, _, splitError := net.SplitHostPort(host); splitError == nil {
+		return name
+	}
+
+	return host
+}
+
+// fullURL returns the host followed by the request uri, e.g. "localhost/wp-login?uid=1234".
+func fullURL(request *http.Request) string {
+	return request.Host + request.URL.RequestURI()
+}
+
+// compileRegexList compiles every regex of the list.
+func compileRegexList(regexList []string) ([]*regexp.Regexp, error) {
+	regexps := make([]*regexp.Regexp, len(regexList))
+
+	for index, regex := range regexList {
+		compiledRegex, compileError := regexp.Compile(regex)
+		if compileError != nil {
+			return nil, fmt.Errorf("error compiling regex %q: %w", regex, compileError)
+		}
+
+		regexps[index] = compiledRegex
+	}
+
+	return regexps, nil
+}
+
+// block logs and records the blocked URL with the reason, then responds according to the matched rule, or the global settings.
+func (blockUrls *traefik_block_regex_urls) block(responseWriter http.ResponseWriter, request *http.Request, client *requestClient, blockMatch *match) {
+	blockMatch.clientIP = client.clientIP()
+
+	if blockMatch.rule == nil || !blockMatch.rule.silent {
+		blockUrls.logger.Printf("URL is blocked (%s): (%s) middleware=%s", blockMatch.reason, blockMatch.url, blockUrls.name)
+	}
+
+	if blockUrls.topBlocked != nil {
+		blockUrls.topBlocked.add(blockMatch.url)
+	}
+
+	if blockUrls.topBlockedIPs != nil {
+		// requests without a known client ip are not counted under an empty ip
+		if blockMatch.clientIP != "" {
+			blockUrls.topBlockedIPs.add(blockMatch.clientIP)
+		}
+	}
+
+	if blockUrls.events != nil || blockUrls.recentBlocks != nil {
+		event := BlockEvent{
+			Middleware: blockUrls.name,
+			Time:       blockUrls.now(),
+			IP:         blockMatch.clientIP,
+			URL:        blockMatch.url,
+			Reason:     blockMatch.reason,
+			Pattern:    blockMatch.pattern,
+		}
+
+		if blockUrls.events != nil {
+			blockUrls.sendEvent(event)
+		}
+
+		if blockUrls.recentBlocks != nil {
+			blockUrls.recentBlocks.add(event)
+		}
+	}
+
+	if blockUrls.auditLog != nil {
+		blockUrls.auditLog.write(auditEntry{
+			Time:   formatAuditTime(blockUrls.now()),
+			IP:     blockMatch.clientIP,
+			Method: request.Method,
+			URL:    blockMatch.url,
+			Reason: blockMatch.reason,
+		})
+	}
+
+	request = blockUrls.decide(request, DecisionBlocked, blockMatch)
+
+	blockUrls.tarpit(request)
+
+	if blockUrls.blockHandler != nil {
+		blockUrls.blockHandler.ServeHTTP(responseWriter, request)
+		return
+	}
+
+	blockUrls.respond(responseWriter, request, blockMatch)
+}
+
+// auditAllowlistedMatch logs and audits a request of a bypassIPs client which matches a rule,
+// e.g. a pentester scanning the site. The request is passed on regardless.
+func (blockUrls *traefik_block_regex_urls) auditAllowlistedMatch(request *http.Request, client *requestClient) {
+	// allowlisted requests are exempt, they must not count toward the rate limits or trackers of everyone else
+	allowlistedMatch := blockUrls.evaluateWith(request, evaluation{dryRun: true, client: client})
+	if allowlistedMatch == nil {
+		return
+	}
+
+	blockUrls.logger.Printf("Allowlisted IP matched a blocked rule (%s): (%s) ip=%s middleware=%s",
+		allowlistedMatch.reason, allowlistedMatch.url, client.clientIP(), blockUrls.name)
+
+	if blockUrls.auditLog != nil {
+		blockUrls.auditLog.write(auditEntry{
+			Time:   formatAuditTime(blockUrls.now()),
+			IP:     client.clientIP(),
+			Method: request.Method,
+			URL:    allowlistedMatch.url,
+			Reason: "allowlisted: " + allowlistedMatch.reason,
+		})
+	}
+}
+
+// matchQueryValues tests every decoded query value against the regexps and returns the first matching one.
+// Values are decoded once by url.Query(); with doubleDecodeQueryValues a second decoding pass is tested as well.
+func (blockUrls *traefik_block_regex_urls) matchQueryValues(request *http.Request, regexps []*regexp.Regexp) *regexp.Regexp {
+	for _, values := range request.URL.Query() {
+		for _, value := range values {
+			candidates := []string{value}
+
+			if blockUrls.doubleDecodeQueryValues {
+				if decodedValue, decodeError := url.QueryUnescape(value); decodeError == nil && decodedValue != value {
+					candidates = append(candidates, decodedValue)
+				}
+			}
+
+			for _, candidate := range candidates {
+				for _, regex := range regexps {
+					if regex.MatchString(candidate) {
+						return regex
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// escapedFragmentParameter carries the hashbang fragment of an AJAX crawlable url, e.g. "/page?_escaped_fragment_=/admin"
+// for "/page#!/admin", in the old AJAX crawling scheme of Google.
+const escapedFragmentParameter = "_escaped_fragment_"
+
+// matchEscapedFragment tests the decoded _escaped_fragment_ values of the query against the regexps and returns the first matching one.
+func matchEscapedFragment(request *http.Request, regexps []*regexp.Regexp) *regexp.Regexp {
+	if !strings.Contains(request.URL.RawQuery, escapedFragmentParameter) {
+		return nil
+	}
+
+	for _, fragment := range request.URL.Query()[escapedFragmentParameter] {
+		for _, regex := range regexps {
+			if regex.MatchString(fragment) {
+				return regex
+			}
+		}
+	}
+
+	return nil
+}
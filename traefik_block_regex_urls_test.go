@@ -2,6 +2,8 @@ package traefik_block_regex_urls_test
 
 import (
 	"context"
+	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -121,6 +123,544 @@ func Test_BlockUrls_ReturnsBlock_IfMatched_AndLocalIpIsNotAllowed(t *testing.T)
 	assertStatusCode(t, recorder.Result(), http.StatusNotFound)
 }
 
+func Test_BlockUrls_DecodeQueryValues_BlocksEncodedPayload(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+
+	cfg.Regex = []string{"(?i)<script"}
+	cfg.DecodeQueryValues = true
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/search?q=%3Cscript%3Ealert(1)%3C/script%3E"), http.StatusNotFound)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/search?q=%253Cscript%253E"), http.StatusOK)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/search?q=scripting"), http.StatusOK)
+}
+
+func Test_BlockUrls_DoubleDecodeQueryValues_BlocksDoubleEncodedPayload(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+
+	cfg.Regex = []string{"(?i)<script"}
+	cfg.DecodeQueryValues = true
+	cfg.DoubleDecodeQueryValues = true
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/search?q=%253Cscript%253E"), http.StatusNotFound)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/search?q=%3Cscript%3E"), http.StatusNotFound)
+}
+
+func Test_BlockUrls_MatchEscapedFragment_BlocksHashbangRoute(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+
+	cfg.Regex = []string{"^/admin"}
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/app?_escaped_fragment_=%2Fadmin%2Fusers"), http.StatusOK)
+
+	cfg.MatchEscapedFragment = true
+	handler = newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/app?_escaped_fragment_=%2Fadmin%2Fusers"), http.StatusForbidden)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/app?lang=en&_escaped_fragment_=/admin"), http.StatusForbidden)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/app?_escaped_fragment_=%2Fhome"), http.StatusOK)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/app?next=%2Fadmin"), http.StatusOK)
+}
+
+func Test_BlockUrls_ReturnsOK_IfMatched_ButDisabled(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+
+	cfg.Regex = []string{"(.*)/wp(.*)"}
+	cfg.Enabled = false
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-login"), http.StatusOK)
+}
+
+func Test_BlockUrls_ReturnsOK_IfNoRules(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-login?uid=1234"), http.StatusOK)
+}
+
+func Test_BlockUrls_Rules_ReturnsRuleStatusCode(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+
+	cfg.Regex = []string{"(.*)/wp-login"}
+	cfg.Rules = []BlockUrls.Rule{
+		{Regex: "(.*)/legacy-webhook$", StatusCode: http.StatusNoContent},
+		{Regex: "(.*)/xmlrpc.php$"},
+	}
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	response := serveRequest(t, handler, "http://localhost/legacy-webhook")
+	assertStatusCode(t, response, http.StatusNoContent)
+	assertEmptyBody(t, response)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/xmlrpc.php"), http.StatusNotFound)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-login"), http.StatusNotFound)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/index.html"), http.StatusOK)
+}
+
+func Test_BlockUrls_MatchScope_PathQuery(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+
+	cfg.Regex = []string{`^/wp(.*)\?uid=1234`}
+	cfg.MatchScope = "pathquery"
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-login?uid=1234"), http.StatusNotFound)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-login?page=2&uid=1234"), http.StatusOK)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-login"), http.StatusOK)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/blog/wp-login?uid=1234"), http.StatusOK)
+}
+
+func Test_BlockUrls_MatchScope_Path(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+
+	cfg.Regex = []string{`^/wp(.*)$`}
+	cfg.ExactMatch = []string{"/xmlrpc.php"}
+	cfg.MatchScope = "path"
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-login?uid=1234"), http.StatusNotFound)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/xmlrpc.php?debug=1"), http.StatusNotFound)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/index.html?next=/wp-login"), http.StatusOK)
+}
+
+func Test_BlockUrls_ExcludesFragment_ByDefault(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+
+	cfg.Regex = []string{`#(.*)admin`, `^localhost/page$`}
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/page#admin"), http.StatusNotFound)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/other#admin"), http.StatusOK)
+}
+
+func Test_BlockUrls_IncludeFragment(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+
+	cfg.Regex = []string{`#(.*)admin`}
+	cfg.IncludeFragment = true
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/other#/admin"), http.StatusNotFound)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/other#/home"), http.StatusOK)
+}
+
+func Test_BlockUrls_TrimLeadingSlash(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+
+	cfg.Regex = []string{`^wp-login`}
+	cfg.MatchScope = "path"
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-login.php"), http.StatusOK)
+
+	cfg.TrimLeadingSlash = true
+
+	handler = newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-login.php"), http.StatusNotFound)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/blog/wp-login.php"), http.StatusOK)
+
+	cfg.Regex = []string{`^/wp-login`}
+
+	handler = newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-login.php"), http.StatusOK)
+}
+
+func Test_BlockUrls_MatchScope_HostPath(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+
+	cfg.Regex = []string{`^localhost/wp-login$`, `^example\.com/admin`}
+	cfg.MatchScope = "hostpath"
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-login"), http.StatusNotFound)
+	assertStatusCode(t, serveRequest(t, handler, "http://LocalHost:8080/WP-Login?uid=1"), http.StatusNotFound)
+	assertStatusCode(t, serveRequest(t, handler, "http://EXAMPLE.com:443/Admin/users"), http.StatusNotFound)
+	assertStatusCode(t, serveRequest(t, handler, "http://[::1]:8080/wp-login"), http.StatusOK)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-login/other"), http.StatusOK)
+}
+
+func Test_BlockUrls_CollapseSlashes(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+
+	cfg.Regex = []string{`^/wp`, `^/index\.html\?next=//home$`}
+	cfg.MatchScope = "pathquery"
+	cfg.StatusCode = 404
+
+	var upstreamPath string
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		upstreamPath = req.URL.Path
+	})
+
+	handler, err := BlockUrls.New(context.Background(), next, cfg, "BlockUrls")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost///wp-login"), http.StatusOK)
+
+	cfg.CollapseSlashes = true
+
+	handler, err = BlockUrls.New(context.Background(), next, cfg, "BlockUrls")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost///wp-login"), http.StatusNotFound)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost//index.html?next=//home"), http.StatusNotFound)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost//blog//index.html"), http.StatusOK)
+
+	if upstreamPath != "//blog//index.html" {
+		t.Errorf("expected the request path to be passed on unchanged, got %q", upstreamPath)
+	}
+}
+
+func Test_BlockUrls_NormalizeHost(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+
+	cfg.Regex = []string{`^xn--mnchen-3ya\.example$`, `^xn--bcher-kva\.example:8080/admin`}
+	cfg.MatchScope = "host"
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://xn--mnchen-3ya.example/"), http.StatusNotFound)
+	assertStatusCode(t, serveRequest(t, handler, "http://münchen.example/"), http.StatusOK)
+
+	cfg.NormalizeHost = true
+
+	handler = newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://xn--mnchen-3ya.example/"), http.StatusNotFound)
+	assertStatusCode(t, serveRequest(t, handler, "http://münchen.example/"), http.StatusNotFound)
+	assertStatusCode(t, serveRequest(t, handler, "http://MÜNCHEN.example/"), http.StatusNotFound)
+	assertStatusCode(t, serveRequest(t, handler, "http://munchen.example/"), http.StatusOK)
+
+	cfg.MatchScope = ""
+
+	handler = newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://bücher.example:8080/admin"), http.StatusNotFound)
+	assertStatusCode(t, serveRequest(t, handler, "http://xn--bcher-kva.example:8080/admin"), http.StatusNotFound)
+	assertStatusCode(t, serveRequest(t, handler, "http://bücher.example:8080/home"), http.StatusOK)
+}
+
+func Test_BlockUrls_TagMode_SetsReasonHeader(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+
+	cfg.Regex = []string{"(.*)/wp-login"}
+	cfg.Mode = "tag"
+	cfg.ReasonHeader = "X-Block-Reason"
+
+	var reason string
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		reason = req.Header.Get("X-Block-Reason")
+	})
+
+	handler, err := BlockUrls.New(context.Background(), next, cfg, "BlockUrls")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-login"), http.StatusOK)
+
+	if reason != "(.*)/wp-login" {
+		t.Errorf("invalid reason header: %q", reason)
+	}
+
+	reason = ""
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/index.html"), http.StatusOK)
+
+	if reason != "" {
+		t.Errorf("unexpected reason header on an unmatched request: %q", reason)
+	}
+}
+
+func Test_BlockUrls_ReasonHeader_DropsClientSentHeader(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login"}
+	cfg.Mode = "tag"
+	cfg.ReasonHeader = "X-Block-Reason"
+	cfg.NeverBlockRoot = true
+
+	var reasons []string
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		reasons = append(reasons, req.Header.Values("X-Block-Reason")...)
+	})
+
+	handler, err := BlockUrls.New(context.Background(), next, cfg, "BlockUrls")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	forged := map[string]string{"X-Block-Reason": "forged"}
+
+	serveRequestWithHeaders(t, handler, "http://localhost/index.html", forged)
+	serveRequestWithHeaders(t, handler, "http://localhost/", forged)
+	serveRequestWithHeaders(t, handler, "http://localhost/wp-login", forged)
+
+	if len(reasons) != 1 || reasons[0] != "(.*)/wp-login" {
+		t.Errorf("expected only the reason of the matched request to reach the backend, got %q", reasons)
+	}
+}
+
+func Test_BlockUrls_ReturnsError_IfInvalidMode(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Mode = "shadow"
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	if _, err := BlockUrls.New(context.Background(), next, cfg, "BlockUrls"); err == nil {
+		t.Fatal("expected an error for an invalid mode")
+	}
+}
+
+func Test_BlockUrls_DisableStringMatch(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Strings = []string{"/phpmyadmin"}
+	cfg.Regex = []string{"(.*)/wp-login"}
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/phpmyadmin"), http.StatusNotFound)
+
+	cfg.DisableStringMatch = true
+
+	handler = newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/phpmyadmin"), http.StatusOK)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-login"), http.StatusNotFound)
+
+	cfg.CombineRegex = true
+	cfg.IncludeStringsInCombined = true
+
+	handler = newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/phpmyadmin"), http.StatusOK)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-login"), http.StatusNotFound)
+}
+
+func Test_BlockUrls_NoMatch_DoesNotAllocate(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector allocates")
+	}
+
+	cfg := BlockUrls.CreateConfig()
+	cfg.MatchScope = "path"
+	cfg.Strings = []string{"/phpmyadmin", ".env"}
+	cfg.Regex = []string{"^/wp(.*)", "(.*)/xmlrpc.php$"}
+
+	tests := map[string]*BlockUrls.Config{
+		"no rules":   BlockUrls.CreateConfig(),
+		"path scope": cfg,
+	}
+
+	for desc, config := range tests {
+		handler := newHandler(t, config)
+
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://localhost/index.html?page=1", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		recorder := httptest.NewRecorder()
+
+		// the path scope matches the request path as is, no url is built unless a rule matches
+		if allocs := testing.AllocsPerRun(100, func() { handler.ServeHTTP(recorder, req) }); allocs != 0 {
+			t.Errorf("%s: expected no allocation for a request matching no rule, got %v", desc, allocs)
+		}
+	}
+}
+
+func Benchmark_BlockUrls_NoRules(b *testing.B) {
+	benchmarkServeHTTP(b, BlockUrls.CreateConfig(), "http://localhost/index.html?page=1")
+}
+
+func Benchmark_BlockUrls_NoMatch(b *testing.B) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.ExactMatch = []string{"localhost/secret"}
+	cfg.Strings = []string{"/phpmyadmin", ".env"}
+	cfg.Regex = []string{"^localhost/wp(.*)", "(.*)/xmlrpc.php$"}
+
+	benchmarkServeHTTP(b, cfg, "http://localhost/index.html?page=1")
+}
+
+func Benchmark_BlockUrls_NoMatch_PathScope(b *testing.B) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.MatchScope = "path"
+	cfg.Strings = []string{"/phpmyadmin", ".env"}
+	cfg.Regex = []string{"^/wp(.*)", "(.*)/xmlrpc.php$"}
+
+	benchmarkServeHTTP(b, cfg, "http://localhost/index.html?page=1")
+}
+
+func Benchmark_BlockUrls_NoMatch_ManyPatterns(b *testing.B) {
+	benchmarkServeHTTP(b, manyPatternsConfig(false), "http://localhost/index.html?page=1")
+}
+
+func Benchmark_BlockUrls_NoMatch_ManyPatterns_Combined(b *testing.B) {
+	benchmarkServeHTTP(b, manyPatternsConfig(true), "http://localhost/index.html?page=1")
+}
+
+func Benchmark_BlockUrls_NoMatch_ManyPatterns_StringMatchDisabled(b *testing.B) {
+	cfg := manyPatternsConfig(false)
+	cfg.DisableStringMatch = true
+
+	benchmarkServeHTTP(b, cfg, "http://localhost/index.html?page=1")
+}
+
+func manyPatternsConfig(combine bool) *BlockUrls.Config {
+	cfg := BlockUrls.CreateConfig()
+	cfg.MatchScope = "path"
+	cfg.CombineRegex = combine
+	cfg.IncludeStringsInCombined = combine
+
+	for i := 0; i < 100; i++ {
+		cfg.Regex = append(cfg.Regex, fmt.Sprintf(`/probe-%d\.php`, i))
+		cfg.Strings = append(cfg.Strings, fmt.Sprintf("/scanner-%d", i))
+	}
+
+	return cfg
+}
+
+func benchmarkServeHTTP(b *testing.B, cfg *BlockUrls.Config, url string) {
+	b.Helper()
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	handler, err := BlockUrls.New(context.Background(), next, cfg, "BlockUrls")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		handler.ServeHTTP(recorder, req)
+	}
+}
+
+func Test_BlockUrls_BlockQueryKeys(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+
+	cfg.BlockQueryKeys = []string{"cmd", "shell"}
+	cfg.StatusCode = 404
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/index.php?cmd=id"), http.StatusNotFound)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/index.php?page=1&shell"), http.StatusNotFound)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/index.php?page=1&command=list"), http.StatusOK)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/cmd/shell"), http.StatusOK)
+}
+
+func Test_BlockUrls_WithoutNext_ReturnsDefaultStatus(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+
+	cfg.Regex = []string{"(.*)/wp(.*)"}
+	cfg.StatusCode = 404
+
+	handler, err := BlockUrls.New(context.Background(), nil, cfg, "BlockUrls")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/index.html"), http.StatusOK)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/wp-login"), http.StatusNotFound)
+
+	cfg.DefaultStatus = http.StatusNoContent
+
+	handler, err = BlockUrls.New(context.Background(), nil, cfg, "BlockUrls")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/index.html"), http.StatusNoContent)
+}
+
+func newHandler(t *testing.T, cfg *BlockUrls.Config) http.Handler {
+	t.Helper()
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	handler, err := BlockUrls.New(context.Background(), next, cfg, "BlockUrls")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return handler
+}
+
+func serveRequest(t *testing.T, handler http.Handler, url string) *http.Response {
+	t.Helper()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, req)
+
+	return recorder.Result()
+}
+
+func assertEmptyBody(t *testing.T, res *http.Response) {
+	t.Helper()
+
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(body) != 0 {
+		t.Errorf("expected an empty body, got %q", body)
+	}
+
+	if contentLength := res.Header.Get("Content-Length"); contentLength != "" {
+		t.Errorf("expected no Content-Length header, got %q", contentLength)
+	}
+}
+
 func assertStatusCode(t *testing.T, req *http.Response, expected int) {
 	t.Helper()
 
@@ -130,3 +670,136 @@ func assertStatusCode(t *testing.T, req *http.Response, expected int) {
 		t.Errorf("invalid status code: %d <> %d", expected, received)
 	}
 }
+
+func Test_BlockUrls_DefaultDeny_BlocksUnlessAllowed(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.DefaultDeny = true
+	cfg.MatchScope = "path"
+	cfg.AllowRegex = []string{`^/api/v1/`, `^/health$`}
+	cfg.Regex = []string{`^/api/v1/internal`}
+
+	handler := newHandler(t, cfg)
+
+	tests := map[string]int{
+		"http://localhost/api/v1/orders":   http.StatusOK,
+		"http://localhost/api/v1/internal": http.StatusOK,
+		"http://localhost/health":          http.StatusOK,
+		"http://localhost/":                http.StatusForbidden,
+		"http://localhost/wp-login.php":    http.StatusForbidden,
+	}
+
+	for url, expected := range tests {
+		response := serveRequest(t, handler, url)
+		if response.StatusCode != expected {
+			t.Errorf("%s: unexpected status code %d, expected %d", url, response.StatusCode, expected)
+		}
+	}
+}
+
+func Test_BlockUrls_DefaultDeny_AllowsBypassIPs(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.DefaultDeny = true
+	cfg.BypassIPs = []string{"203.0.113.0/24"}
+
+	handler := newHandler(t, cfg)
+
+	response := serveRequestWithHeaders(t, handler, "http://localhost/", map[string]string{"X-Forwarded-For": "203.0.113.7"})
+	assertStatusCode(t, response, http.StatusOK)
+
+	response = serveRequestWithHeaders(t, handler, "http://localhost/", map[string]string{"X-Forwarded-For": "198.51.100.7"})
+	assertStatusCode(t, response, http.StatusForbidden)
+}
+
+func Test_BlockUrls_AllowQueryStrings_BypassesBlock(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/download(.*)"}
+	cfg.AllowQueryStrings = []string{"file=report.pdf&token=abc"}
+
+	handler := newHandler(t, cfg)
+
+	tests := map[string]int{
+		"http://localhost/download?file=report.pdf&token=abc":        http.StatusOK,
+		"http://localhost/download?token=abc&file=report.pdf":        http.StatusForbidden,
+		"http://localhost/download?file=report.pdf&token=abc&cmd=id": http.StatusForbidden,
+		"http://localhost/download":                                  http.StatusForbidden,
+	}
+
+	for url, expected := range tests {
+		response := serveRequest(t, handler, url)
+		if response.StatusCode != expected {
+			t.Errorf("%s: unexpected status code %d, expected %d", url, response.StatusCode, expected)
+		}
+	}
+}
+
+func Test_BlockUrls_TreatHeadAsGet_AppliesGetRulesToHead(t *testing.T) {
+	for _, treatHeadAsGet := range []bool{true, false} {
+		cfg := BlockUrls.CreateConfig()
+		cfg.Rules = []BlockUrls.Rule{
+			{Regex: "(.*)/backup.zip", Methods: []string{"GET"}},
+			{Regex: "(.*)/upload", Methods: []string{"POST"}},
+		}
+		cfg.TreatHeadAsGet = treatHeadAsGet
+
+		handler := newHandler(t, cfg)
+
+		expectedHead := http.StatusOK
+		if treatHeadAsGet {
+			expectedHead = http.StatusForbidden
+		}
+
+		tests := []struct {
+			method   string
+			url      string
+			expected int
+		}{
+			{http.MethodGet, "http://localhost/backup.zip", http.StatusForbidden},
+			{http.MethodHead, "http://localhost/backup.zip", expectedHead},
+			{http.MethodPost, "http://localhost/backup.zip", http.StatusOK},
+			{http.MethodPost, "http://localhost/upload", http.StatusForbidden},
+			{http.MethodGet, "http://localhost/upload", http.StatusOK},
+		}
+
+		for _, test := range tests {
+			req, err := http.NewRequestWithContext(context.Background(), test.method, test.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+
+			if recorder.Code != test.expected {
+				t.Errorf("treatHeadAsGet=%t %s %s: unexpected status code %d, expected %d", treatHeadAsGet, test.method, test.url, recorder.Code, test.expected)
+			}
+		}
+	}
+}
+
+func Test_BlockUrls_NeverBlockRoot_PassesRootThrough(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"^localhost/"}
+
+	handler := newHandler(t, cfg)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/"), http.StatusForbidden)
+
+	cfg.NeverBlockRoot = true
+	handler = newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/"), http.StatusOK)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/?cmd=id"), http.StatusOK)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/index.html"), http.StatusForbidden)
+}
+
+func Test_BlockUrls_SkipExtensions(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/(style|x)"}
+	cfg.SkipExtensions = []string{".css", "JS"}
+
+	handler := newHandler(t, cfg)
+
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/style.CSS"), http.StatusOK)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/style.js?v=2"), http.StatusOK)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/x.php"), http.StatusForbidden)
+	assertStatusCode(t, serveRequest(t, handler, "http://localhost/style"), http.StatusForbidden)
+}
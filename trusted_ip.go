@@ -0,0 +1,58 @@
+package traefik_block_regex_urls
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// trustedIPHeader reads the client ip from a header, trusted only with a valid signature.
+type trustedIPHeader struct {
+	header          string
+	signatureHeader string
+	secret          []byte
+}
+
+// newTrustedIPHeader returns nil if no header is configured.
+func newTrustedIPHeader(config *Config) (*trustedIPHeader, error) {
+	if config.TrustedIPHeader == "" {
+		return nil, nil
+	}
+
+	if config.TrustedIPSignatureHeader == "" || config.TrustedIPSecret == "" {
+		return nil, errors.New("trustedIPHeader requires trustedIPSignatureHeader and trustedIPSecret")
+	}
+
+	return &trustedIPHeader{
+		header:          config.TrustedIPHeader,
+		signatureHeader: config.TrustedIPSignatureHeader,
+		secret:          []byte(config.TrustedIPSecret),
+	}, nil
+}
+
+// ip returns the ip of the header if the signature header holds the hex encoded HMAC-SHA256 of the header value
+// with the secret, or nil if the header is missing, the signature does not match or the value is not an ip.
+func (trusted *trustedIPHeader) ip(request *http.Request) net.IP {
+	value := strings.TrimSpace(request.Header.Get(trusted.header))
+	if value == "" {
+		return nil
+	}
+
+	signature, decodeError := hex.DecodeString(strings.TrimSpace(request.Header.Get(trusted.signatureHeader)))
+	if decodeError != nil {
+		return nil
+	}
+
+	mac := hmac.New(sha256.New, trusted.secret)
+	mac.Write([]byte(value))
+
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return nil
+	}
+
+	return net.ParseIP(value)
+}
@@ -0,0 +1,52 @@
+package traefik_block_regex_urls
+
+import (
+	"regexp"
+	"regexp/syntax"
+)
+
+// Warnings returned by ValidatePattern.
+const (
+	warningUnanchored   = "unanchored"
+	warningMatchesEmpty = "matches empty string"
+	warningInvalidRegex = "invalid regex: "
+)
+
+// ValidatePattern checks a regex pattern for common mistakes, e.g. in a CI check of the configuration.
+// Returns the warnings, or nil if the pattern looks fine. An invalid pattern only returns the compile error.
+func ValidatePattern(pattern string) []string {
+	regex, compileError := regexp.Compile(pattern)
+	if compileError != nil {
+		return []string{warningInvalidRegex + compileError.Error()}
+	}
+
+	var warnings []string
+
+	if !isAnchored(pattern) {
+		warnings = append(warnings, warningUnanchored)
+	}
+
+	if regex.MatchString("") {
+		warnings = append(warnings, warningMatchesEmpty)
+	}
+
+	return warnings
+}
+
+// isAnchored reports whether the pattern starts with a ^ or \A anchor, after any flags like (?i).
+func isAnchored(pattern string) bool {
+	parsed, parseError := syntax.Parse(pattern, syntax.Perl)
+	if parseError != nil {
+		return false
+	}
+
+	for parsed.Op == syntax.OpConcat || parsed.Op == syntax.OpCapture {
+		if len(parsed.Sub) == 0 {
+			return false
+		}
+
+		parsed = parsed.Sub[0]
+	}
+
+	return parsed.Op == syntax.OpBeginText || parsed.Op == syntax.OpBeginLine
+}
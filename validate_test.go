@@ -0,0 +1,33 @@
+package traefik_block_regex_urls_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+func Test_ValidatePattern(t *testing.T) {
+	tests := map[string][]string{
+		"^/wp-login":      nil,
+		`(?i)^/wp-login`:  nil,
+		`\A/xmlrpc\.php$`: nil,
+		"(^/admin)":       nil,
+		"/wp-login":       {"unanchored"},
+		"^(/admin)?":      {"matches empty string"},
+		".*":              {"unanchored", "matches empty string"},
+	}
+
+	for pattern, expected := range tests {
+		warnings := BlockUrls.ValidatePattern(pattern)
+		if !slices.Equal(warnings, expected) {
+			t.Errorf("%q: unexpected warnings %q, expected %q", pattern, warnings, expected)
+		}
+	}
+
+	warnings := BlockUrls.ValidatePattern("(.*/wp-login")
+	if len(warnings) != 1 || !strings.HasPrefix(warnings[0], "invalid regex: ") {
+		t.Errorf("unexpected warnings for an invalid regex %q", warnings)
+	}
+}
@@ -0,0 +1,203 @@
+package traefik_block_regex_urls
+
+import (
+	"context"
+	"log"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultVerifiedBotsCacheTTL is how long a reverse DNS verification result is cached.
+const defaultVerifiedBotsCacheTTL = time.Hour
+
+// defaultVerifiedBotsMaxEntries bounds the number of cached verifications, which clients can grow by rotating ips.
+const defaultVerifiedBotsMaxEntries = 10000
+
+// verifiedBotsLookupTimeout bounds the DNS lookups of a single verification.
+const verifiedBotsLookupTimeout = 2 * time.Second
+
+// VerifiedBot is a well-known bot, identified by its User-Agent and verified by the reverse DNS of its ip.
+type VerifiedBot struct {
+	UAContains   string `yaml:"uaContains"`
+	DomainSuffix string `yaml:"domainSuffix"`
+}
+
+// Resolver is the subset of net.Resolver used to verify bots, replaceable for tests and embedders.
+type Resolver interface {
+	LookupAddr(ctx context.Context, addr string) ([]string, error)
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+type verification struct {
+	verified bool
+	expires  time.Time
+}
+
+// cachedVerification is a cache key and the expiry of its verification.
+type cachedVerification struct {
+	key     string
+	expires time.Time
+}
+
+// botVerifier checks the reverse DNS of bot ips and caches the results.
+// When the cache is full, the verification cached first is dropped first.
+type botVerifier struct {
+	bots       []VerifiedBot
+	resolver   Resolver
+	ttl        time.Duration
+	maxEntries int
+	name       string
+	logger     *log.Logger
+
+	mu    sync.Mutex
+	cache map[string]verification
+	// order holds the keys in the order they were cached, which is also the order they expire in;
+	// entries of keys verified again later are stale.
+	order []cachedVerification
+}
+
+func newBotVerifier(bots []VerifiedBot, ttl time.Duration, maxEntries int, name string, logger *log.Logger) *botVerifier {
+	return &botVerifier{
+		bots:       bots,
+		resolver:   net.DefaultResolver,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		name:       name,
+		logger:     logger,
+		cache:      map[string]verification{},
+	}
+}
+
+// isVerifiedBot reports whether the User-Agent claims to be one of the bots and the ip belongs to its domain.
+// The ip must reverse resolve to a host ending with the domain suffix, which in turn must resolve back to the ip.
+func (verifier *botVerifier) isVerifiedBot(userAgent string, ip string, now time.Time) bool {
+	if ip == "" {
+		return false
+	}
+
+	bots := []VerifiedBot{}
+	families := []string{}
+
+	for _, bot := range verifier.bots {
+		if bot.UAContains != "" && strings.Contains(userAgent, bot.UAContains) {
+			bots = append(bots, bot)
+			families = append(families, bot.UAContains)
+		}
+	}
+
+	if len(bots) == 0 {
+		return false
+	}
+
+	// keyed on the bots the User-Agent claims, any other part of it varies freely without changing the verification
+	cacheKey := ip + "|" + strings.Join(families, "|")
+
+	verifier.mu.Lock()
+	cached, found := verifier.cache[cacheKey]
+	verifier.mu.Unlock()
+
+	if found && now.Before(cached.expires) {
+		return cached.verified
+	}
+
+	verified := verifier.verify(bots, ip)
+
+	verifier.mu.Lock()
+	verifier.removeExpired(now)
+	verifier.store(cacheKey, verification{verified: verified, expires: now.Add(verifier.ttl)})
+	verifier.mu.Unlock()
+
+	return verified
+}
+
+// store caches the verification, dropping the oldest ones beyond maxEntries. The caller must hold the lock.
+func (verifier *botVerifier) store(key string, cached verification) {
+	verifier.cache[key] = cached
+	verifier.order = append(verifier.order, cachedVerification{key: key, expires: cached.expires})
+
+	for len(verifier.cache) > verifier.maxEntries {
+		verifier.dropOldest()
+	}
+
+	// stale entries pile up when keys are verified again, drop them once they outnumber the live ones
+	if len(verifier.order) > 2*len(verifier.cache)+1 {
+		live := make([]cachedVerification, 0, len(verifier.cache))
+		for _, entry := range verifier.order {
+			if verifier.isLive(entry) {
+				live = append(live, entry)
+			}
+		}
+
+		verifier.order = live
+	}
+}
+
+// dropOldest drops the verification cached first. The caller must hold the lock.
+func (verifier *botVerifier) dropOldest() {
+	for len(verifier.order) > 0 {
+		entry := verifier.order[0]
+		verifier.order = verifier.order[1:]
+
+		if verifier.isLive(entry) {
+			delete(verifier.cache, entry.key)
+			return
+		}
+	}
+}
+
+// isLive reports whether the entry is the current verification of its key. The caller must hold the lock.
+func (verifier *botVerifier) isLive(entry cachedVerification) bool {
+	cached, found := verifier.cache[entry.key]
+
+	return found && cached.expires.Equal(entry.expires)
+}
+
+func (verifier *botVerifier) verify(bots []VerifiedBot, ip string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), verifiedBotsLookupTimeout)
+	defer cancel()
+
+	hosts, lookupError := verifier.resolver.LookupAddr(ctx, ip)
+	if lookupError != nil {
+		verifier.logger.Printf("error verifying bot ip %s: %v middleware=%s", ip, lookupError, verifier.name)
+		return false
+	}
+
+	for _, host := range hosts {
+		host = strings.ToLower(strings.TrimSuffix(host, "."))
+
+		for _, bot := range bots {
+			suffix := strings.ToLower(strings.TrimPrefix(bot.DomainSuffix, "."))
+			if host != suffix && !strings.HasSuffix(host, "."+suffix) {
+				continue
+			}
+
+			// forward-confirm the host, anyone can publish a reverse record pointing to any domain
+			addresses, lookupError := verifier.resolver.LookupHost(ctx, host)
+			if lookupError != nil {
+				verifier.logger.Printf("error confirming bot host %s: %v middleware=%s", host, lookupError, verifier.name)
+				continue
+			}
+
+			if slices.ContainsFunc(addresses, func(address string) bool { return net.ParseIP(address).Equal(net.ParseIP(ip)) }) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// removeExpired drops the expired cache entries, which are the oldest ones. The caller must hold the lock.
+func (verifier *botVerifier) removeExpired(now time.Time) {
+	for len(verifier.order) > 0 && !now.Before(verifier.order[0].expires) {
+		entry := verifier.order[0]
+		verifier.order = verifier.order[1:]
+
+		if verifier.isLive(entry) {
+			delete(verifier.cache, entry.key)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package traefik_block_regex_urls_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	BlockUrls "github.com/shantanugadgil/traefik-block-regex-urls"
+)
+
+type fakeResolver struct {
+	addrs   map[string][]string
+	hosts   map[string][]string
+	lookups int
+}
+
+func (resolver *fakeResolver) LookupAddr(_ context.Context, addr string) ([]string, error) {
+	resolver.lookups++
+
+	if names, found := resolver.addrs[addr]; found {
+		return names, nil
+	}
+
+	return nil, errors.New("no such host")
+}
+
+func (resolver *fakeResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	if addresses, found := resolver.hosts[host]; found {
+		return addresses, nil
+	}
+
+	return nil, errors.New("no such host")
+}
+
+func Test_BlockUrls_VerifiedBots_BypassesBlocking(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login"}
+	cfg.VerifiedBots = []BlockUrls.VerifiedBot{
+		{UAContains: "Googlebot", DomainSuffix: "googlebot.com"},
+	}
+	cfg.ForwardedIPDepth = 1
+	cfg.StatusCode = 404
+
+	resolver := &fakeResolver{
+		addrs: map[string][]string{
+			"66.249.66.1": {"crawl-66-249-66-1.googlebot.com."},
+			"2.56.20.1":   {"crawl.googlebot.com.evil.example."},
+			"2.56.20.2":   {"crawl-66-249-66-2.googlebot.com."},
+		},
+		hosts: map[string][]string{
+			"crawl-66-249-66-1.googlebot.com": {"66.249.66.1"},
+			"crawl-66-249-66-2.googlebot.com": {"66.249.66.2"},
+		},
+	}
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	handler, err := BlockUrls.NewWithOptions(context.Background(), next, cfg, "BlockUrls", BlockUrls.WithResolver(resolver))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	googlebot := "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)"
+
+	tests := []struct {
+		ip        string
+		userAgent string
+		expected  int
+	}{
+		{"66.249.66.1", googlebot, http.StatusOK},
+		{"66.249.66.1", "curl/8.0", http.StatusNotFound},
+		{"2.56.20.1", googlebot, http.StatusNotFound},
+		{"2.56.20.2", googlebot, http.StatusNotFound},
+		{"2.56.20.3", googlebot, http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		headers := map[string]string{"X-Forwarded-For": test.ip, "User-Agent": test.userAgent}
+
+		assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/wp-login", headers), test.expected)
+	}
+
+	// a real bot ip in a spoofed leftmost entry is not the client ip
+	headers := map[string]string{"X-Forwarded-For": "66.249.66.1, 2.56.20.9", "User-Agent": googlebot}
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/wp-login", headers), http.StatusNotFound)
+
+	lookups := resolver.lookups
+
+	headers = map[string]string{"X-Forwarded-For": "66.249.66.1", "User-Agent": googlebot}
+	assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/wp-login", headers), http.StatusOK)
+
+	if resolver.lookups != lookups {
+		t.Errorf("expected the verification to be cached, got %d new lookups", resolver.lookups-lookups)
+	}
+}
+
+func Test_BlockUrls_VerifiedBots_CachePerBot(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.Regex = []string{"(.*)/wp-login"}
+	cfg.VerifiedBots = []BlockUrls.VerifiedBot{
+		{UAContains: "Googlebot", DomainSuffix: "googlebot.com"},
+	}
+	cfg.ForwardedIPDepth = 1
+	cfg.VerifiedBotsMaxEntries = 1
+
+	resolver := &fakeResolver{
+		addrs: map[string][]string{
+			"66.249.66.1": {"crawl-66-249-66-1.googlebot.com."},
+			"66.249.66.2": {"crawl-66-249-66-2.googlebot.com."},
+		},
+		hosts: map[string][]string{
+			"crawl-66-249-66-1.googlebot.com": {"66.249.66.1"},
+			"crawl-66-249-66-2.googlebot.com": {"66.249.66.2"},
+		},
+	}
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {})
+
+	handler, err := BlockUrls.NewWithOptions(context.Background(), next, cfg, "BlockUrls", BlockUrls.WithResolver(resolver))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	serve := func(ip string, userAgent string) {
+		t.Helper()
+
+		headers := map[string]string{"X-Forwarded-For": ip, "User-Agent": userAgent}
+		assertStatusCode(t, serveRequestWithHeaders(t, handler, "http://localhost/wp-login", headers), http.StatusOK)
+	}
+
+	// a varying User-Agent of the same bot reuses the verification
+	serve("66.249.66.1", "Mozilla/5.0 (compatible; Googlebot/2.1)")
+	serve("66.249.66.1", "Mozilla/5.0 (compatible; Googlebot/2.2; x1)")
+
+	if resolver.lookups != 1 {
+		t.Errorf("expected 1 lookup, got %d", resolver.lookups)
+	}
+
+	// the cache holds a single verification, the second ip drops the first
+	serve("66.249.66.2", "Googlebot")
+	serve("66.249.66.1", "Googlebot")
+
+	if resolver.lookups != 3 {
+		t.Errorf("expected 3 lookups, got %d", resolver.lookups)
+	}
+}
+
+func Test_BlockUrls_VerifiedBots_RequiresTrustedClientIP(t *testing.T) {
+	cfg := BlockUrls.CreateConfig()
+	cfg.VerifiedBots = []BlockUrls.VerifiedBot{
+		{UAContains: "Googlebot", DomainSuffix: "googlebot.com"},
+	}
+
+	if _, err := BlockUrls.New(context.Background(), nil, cfg, "BlockUrls"); err == nil || !strings.Contains(err.Error(), "forwardedIPDepth") {
+		t.Errorf("expected verifiedBots without a trusted client ip to be refused, got %v", err)
+	}
+}